@@ -4,6 +4,8 @@
 package quic
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/pion/quic/internal/wrapper"
@@ -12,24 +14,113 @@ import (
 
 // BidirectionalStream represents a bidirectional Quic stream.
 type BidirectionalStream struct {
-	s *wrapper.Stream
+	s       *wrapper.Stream
+	session *wrapper.Conn
+	wb      *writeBuffer
+	rb      *readBuffer
 }
 
-// Write writes data to the stream.
+func newBidirectionalStream(
+	s *wrapper.Stream, session *wrapper.Conn, maxWriteBufferedAmount int, lifetime *streamLifetime,
+) *BidirectionalStream {
+	return &BidirectionalStream{
+		s:       s,
+		session: session,
+		wb:      newWriteBuffer(s, maxWriteBufferedAmount, lifetime),
+		rb:      newReadBuffer(s, lifetime),
+	}
+}
+
+// Write writes data to the stream. Data queued with QueueWrite is sent
+// first.
 func (s *BidirectionalStream) Write(data StreamWriteParameters) error {
-	_, err := s.s.WriteQuic(data.Data, data.Finished)
+	_, err := s.wb.write(data.Data, data.Finished)
 
 	return err
 }
 
+// QueueWrite copies data into the stream's write buffer and returns without
+// waiting for it to be sent. It fails with ErrWriteBufferFull if the data
+// does not fit, see WaitForWriteBufferedAmountBelow.
+func (s *BidirectionalStream) QueueWrite(data StreamWriteParameters) error {
+	return s.wb.queue(data.Data, data.Finished)
+}
+
+// WriteBufferedAmount returns the number of bytes queued but not yet
+// handed to the QUIC stack.
+func (s *BidirectionalStream) WriteBufferedAmount() int {
+	return s.wb.bufferedAmount()
+}
+
+// MaxWriteBufferedAmount returns the size of the write buffer.
+func (s *BidirectionalStream) MaxWriteBufferedAmount() int {
+	return s.wb.max
+}
+
+// WaitForWriteBufferedAmountBelow blocks until WriteBufferedAmount is at or
+// below threshold, or ctx is done. It fails if sending queued data failed.
+func (s *BidirectionalStream) WaitForWriteBufferedAmountBelow(ctx context.Context, threshold int) error {
+	return s.wb.waitBelow(ctx, threshold, false)
+}
+
 // ReadInto reads from the stream into the buffer.
 func (s *BidirectionalStream) ReadInto(data []byte) (StreamReadResult, error) {
-	n, fin, err := s.s.ReadQuic(data)
+	n, fin, err := s.rb.read(data)
 
 	return StreamReadResult{
 		Amount:   n,
 		Finished: fin,
-	}, err
+	}, wrapStreamError(err, false)
+}
+
+// Read implements io.Reader. It returns io.EOF once the peer has finished
+// the stream.
+func (s *BidirectionalStream) Read(p []byte) (int, error) {
+	n, _, err := s.rb.read(p)
+
+	return n, wrapStreamError(err, false)
+}
+
+// WriteTo implements io.WriterTo, copying the stream into w until the peer
+// finishes it.
+func (s *BidirectionalStream) WriteTo(w io.Writer) (int64, error) {
+	return copyChunks(s.Read, w.Write)
+}
+
+// Writer returns an io.WriteCloser for the sending side of the stream.
+func (s *BidirectionalStream) Writer() *StreamWriter {
+	return &StreamWriter{b: s.wb}
+}
+
+// CloseWrite finishes the sending side of the stream without waiting. The
+// FIN is sent to the peer after any queued or pending data. The stream can
+// still be read from.
+func (s *BidirectionalStream) CloseWrite() error {
+	_, err := s.wb.close()
+
+	return err
+}
+
+// Close implements io.Closer. Like CloseWrite it only finishes the sending
+// side; use StopSending to abort reading.
+func (s *BidirectionalStream) Close() error {
+	return s.CloseWrite()
+}
+
+// ReadBufferedAmount returns the number of bytes that WaitForReadable
+// pulled from the QUIC stack and that have not been read yet. Unlike the
+// W3C readBufferedAmount it does not count data the stack received while
+// nobody waited, so it can be 0 although a read would not block; use
+// WaitForReadable instead of polling it.
+func (s *BidirectionalStream) ReadBufferedAmount() int {
+	return s.rb.bufferedAmount()
+}
+
+// WaitForReadable blocks until amount bytes can be read without blocking,
+// the peer finished the stream, or ctx is done. It must not be called
+// concurrently with reads.
+func (s *BidirectionalStream) WaitForReadable(ctx context.Context, amount int) error {
+	return wrapStreamError(s.rb.waitReadable(ctx, amount), false)
 }
 
 // StreamID returns the ID of the QuicStream.
@@ -37,10 +128,63 @@ func (s *BidirectionalStream) StreamID() StreamID {
 	return StreamID(s.s.StreamID())
 }
 
+// Reset aborts writing to the stream, following abortWriting. Data that
+// has not been delivered yet is discarded and the peer's reads fail with a
+// StreamError carrying code.
+func (s *BidirectionalStream) Reset(code ErrorCode) error {
+	if err := code.validate(); err != nil {
+		return err
+	}
+	s.s.CancelWrite(uint64(code))
+	s.wb.lifetime.closeWrite()
+
+	return nil
+}
+
+// StopSending aborts reading from the stream, following abortReading. The
+// peer's writes fail with a StreamError carrying code.
+func (s *BidirectionalStream) StopSending(code ErrorCode) error {
+	if err := code.validate(); err != nil {
+		return err
+	}
+	s.s.CancelRead(uint64(code))
+	s.rb.lifetime.closeRead()
+
+	return nil
+}
+
 // SetDeadline sets read and write deadlines associated with the stream.
 // A zero value for t means Read and Write will not timeout.
 func (s *BidirectionalStream) SetDeadline(t time.Time) error {
-	return s.s.SetDeadline(t)
+	// A cancelled WaitForReadable restores the recorded read deadline, and
+	// the flusher retries once it sees the new write deadline.
+	s.rb.setReadDeadline(t)
+	err := s.s.SetDeadline(t)
+	s.wb.setWriteDeadline(t)
+
+	return err
+}
+
+// SetReadDeadline sets the deadline for future Read calls. A zero value for t means Read will not time out.
+func (s *BidirectionalStream) SetReadDeadline(t time.Time) error {
+	s.rb.setReadDeadline(t)
+
+	return s.s.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future Write calls. A zero value for t means Write will not time out.
+func (s *BidirectionalStream) SetWriteDeadline(t time.Time) error {
+	// The flusher retries once it sees the new deadline, so the stream's
+	// deadline has to be moved first.
+	err := s.s.SetWriteDeadline(t)
+	s.wb.setWriteDeadline(t)
+
+	return err
+}
+
+// NetConn returns a net.Conn backed by the stream.
+func (s *BidirectionalStream) NetConn() *StreamConn {
+	return &StreamConn{s: s}
 }
 
 // Detach detaches the underlying quic-go stream.
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import "crypto/tls"
+
+// ClientAuthType is the policy of a server for client certificates.
+type ClientAuthType int
+
+const (
+	// ClientAuthRequireAny requires a client certificate but does not verify
+	// it. This is the default, since peers are commonly identified by
+	// certificate fingerprints.
+	ClientAuthRequireAny ClientAuthType = iota
+
+	// ClientAuthNone does not request a client certificate.
+	ClientAuthNone
+
+	// ClientAuthRequest requests a client certificate but does not require
+	// or verify it.
+	ClientAuthRequest
+
+	// ClientAuthRequireAndVerify requires a client certificate that is
+	// signed by one of Config.ClientCAs.
+	ClientAuthRequireAndVerify
+)
+
+func (t ClientAuthType) tlsClientAuth() tls.ClientAuthType {
+	switch t {
+	case ClientAuthNone:
+		return tls.NoClientCert
+	case ClientAuthRequest:
+		return tls.RequestClientCert
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert
+	case ClientAuthRequireAny:
+		return tls.RequireAnyClientCert
+	default:
+		return tls.RequireAnyClientCert
+	}
+}
@@ -0,0 +1,274 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/quic/internal/wrapper"
+)
+
+// Config is used to hold the configuration of StartBase.
+type Config struct {
+	Client        bool
+	Certificate   *x509.Certificate
+	PrivateKey    crypto.PrivateKey
+	LoggerFactory logging.LoggerFactory
+
+	// RootCAs is the set of certificate authorities used to verify the
+	// server certificate. If neither RootCAs nor ServerName is set, any
+	// server certificate is accepted and the caller has to verify the peer,
+	// e.g. through VerifyPeerCertificate. If only ServerName is set the
+	// system roots are used.
+	RootCAs *x509.CertPool
+	// ServerName is used to verify the hostname of the server certificate.
+	ServerName string
+	// ClientAuth is the policy of a server for client certificates.
+	ClientAuth ClientAuthType
+	// ClientCAs is the set of certificate authorities used to verify client
+	// certificates when ClientAuth is ClientAuthRequireAndVerify.
+	ClientCAs *x509.CertPool
+	// VerifyPeerCertificate, if not nil, is called after the normal
+	// certificate verification. See tls.Config for details.
+	VerifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+	// RemoteFingerprints, if not empty, fails the handshake unless the
+	// peer certificate matches one of the fingerprints. It is used by
+	// peer-to-peer Transports which exchange Parameters by signaling. The
+	// check also runs on resumed sessions. A server has to request client
+	// certificates, so it cannot be combined with ClientAuthNone.
+	RemoteFingerprints []Fingerprint
+	// KeyLogWriter receives the TLS secrets in NSS key log format, so
+	// captures can be decrypted with tools like Wireshark. If not set, the
+	// secrets are appended to the file named by the SSLKEYLOGFILE
+	// environment variable, if any. Using it compromises security.
+	KeyLogWriter io.Writer
+	// NextProtos is the list of supported ALPN protocols, in order of
+	// preference. The handshake fails if the peers share none. Defaults to
+	// "pion-quic".
+	NextProtos []string
+
+	// ClientSessionCache caches TLS session tickets on the client, so that
+	// later connections to the same server can resume the session. It can be
+	// shared between Configs, see tls.NewLRUClientSessionCache.
+	ClientSessionCache tls.ClientSessionCache
+	// Enable0RTT lets a client with a cached session send data before the
+	// handshake completed, and lets a server accept such data. 0-RTT data
+	// can be replayed by an attacker, so it should only be used for
+	// idempotent requests. If the server rejects 0-RTT, streams opened
+	// before the handshake completed fail with Err0RTTRejected.
+	Enable0RTT bool
+	// Accept0RTT decides whether a server accepts 0-RTT data from a client,
+	// for example to limit replay to verified addresses. All 0-RTT
+	// attempts are accepted if it is nil and Enable0RTT is set.
+	Accept0RTT func(remoteAddr net.Addr, addrVerified bool) bool
+
+	// FramePackets frames packets with the 2-byte length prefix of RFC 4571
+	// when StartBase or NewEndpoint run over a stream-oriented conn, such as
+	// TCP. It must not be set for conns that keep packet boundaries, such
+	// as an ICE path over a TCP candidate pair.
+	FramePackets bool
+
+	// EnableDatagrams enables unreliable datagrams (RFC 9221). Both peers
+	// need to enable them before datagrams can be sent.
+	EnableDatagrams bool
+	// DatagramHighWaterMark is the number of outgoing datagrams that are
+	// queued before the oldest queued datagram is dropped. Defaults to 32.
+	// Set it to 1 if only the latest datagram matters.
+	DatagramHighWaterMark int
+
+	// MaxWriteBufferedAmount is the size in bytes of each stream's write
+	// buffer, see QueueWrite. Defaults to 1 MiB.
+	MaxWriteBufferedAmount int
+
+	// MaxIdleTimeout is the maximum duration that may pass without any
+	// network activity before the connection is closed. The lower value of
+	// both peers is used. Defaults to 30 seconds.
+	MaxIdleTimeout time.Duration
+	// HandshakeIdleTimeout is the idle timeout before the handshake has
+	// completed. Defaults to 5 seconds.
+	HandshakeIdleTimeout time.Duration
+	// KeepAlivePeriod is the period in which keep-alive packets are sent.
+	// Defaults to 30 seconds, a negative value disables keep-alives.
+	KeepAlivePeriod time.Duration
+
+	// InitialStreamReceiveWindow is the initial stream-level flow control
+	// window. Defaults to 512 KB.
+	InitialStreamReceiveWindow uint64
+	// MaxStreamReceiveWindow is the maximum stream-level flow control
+	// window. Defaults to 3 MB.
+	MaxStreamReceiveWindow uint64
+	// InitialConnectionReceiveWindow is the initial connection-level flow
+	// control window. Defaults to 512 KB.
+	InitialConnectionReceiveWindow uint64
+	// MaxConnectionReceiveWindow is the maximum connection-level flow
+	// control window. Defaults to 4.5 MB.
+	MaxConnectionReceiveWindow uint64
+
+	// MaxIncomingStreams is the number of concurrent bidirectional streams
+	// the peer may open. Defaults to 1000, a negative value disallows them.
+	MaxIncomingStreams int64
+	// MaxIncomingUniStreams is the number of concurrent unidirectional
+	// streams the peer may open. Defaults to 1000, a negative value
+	// disallows them.
+	MaxIncomingUniStreams int64
+
+	// InitialPacketSize is the initial size of sent packets, before path MTU
+	// discovery. Values below 1200 are invalid. Defaults to 1280.
+	InitialPacketSize uint16
+
+	// QlogDir enables qlog tracing. Each connection writes its trace to
+	// <connection ID>_<client|server>.sqlog in this directory, which is
+	// created if needed.
+	QlogDir string
+	// QlogWriter enables qlog tracing to the returned destination, which is
+	// closed with the connection. It takes precedence over QlogDir.
+	// Returning nil disables tracing of the connection.
+	QlogWriter func(isClient bool, connID string) io.WriteCloser
+}
+
+func (c *Config) clone() *wrapper.Config {
+	return &wrapper.Config{
+		Certificate: c.Certificate,
+		PrivateKey:  c.PrivateKey,
+		SkipVerify:  c.RootCAs == nil && c.ServerName == "",
+
+		RootCAs:               c.RootCAs,
+		ServerName:            c.ServerName,
+		ClientAuth:            c.ClientAuth.tlsClientAuth(),
+		ClientCAs:             c.ClientCAs,
+		VerifyPeerCertificate: c.VerifyPeerCertificate,
+		VerifyConnection:      c.verifyConnection(),
+		KeyLogWriter:          c.keyLogWriter(),
+		NextProtos:            c.NextProtos,
+		ClientSessionCache:    c.ClientSessionCache,
+
+		Enable0RTT: c.Enable0RTT,
+		Accept0RTT: c.Accept0RTT,
+
+		EnableDatagrams: c.EnableDatagrams,
+
+		MaxIdleTimeout:       c.MaxIdleTimeout,
+		HandshakeIdleTimeout: c.HandshakeIdleTimeout,
+		KeepAlivePeriod:      c.KeepAlivePeriod,
+
+		InitialStreamReceiveWindow:     c.InitialStreamReceiveWindow,
+		MaxStreamReceiveWindow:         c.MaxStreamReceiveWindow,
+		InitialConnectionReceiveWindow: c.InitialConnectionReceiveWindow,
+		MaxConnectionReceiveWindow:     c.MaxConnectionReceiveWindow,
+
+		MaxIncomingStreams:    c.MaxIncomingStreams,
+		MaxIncomingUniStreams: c.MaxIncomingUniStreams,
+
+		InitialPacketSize: c.InitialPacketSize,
+
+		QlogWriter: c.qlogWriter(),
+	}
+}
+
+// keyLogWriter returns the KeyLogWriter, or one appending to SSLKEYLOGFILE.
+func (c *Config) keyLogWriter() io.Writer {
+	if c.KeyLogWriter != nil {
+		return c.KeyLogWriter
+	}
+	if path := os.Getenv("SSLKEYLOGFILE"); path != "" {
+		return keyLogFile(path)
+	}
+
+	return nil
+}
+
+// keyLogFile appends to the named file. The file is opened for each write,
+// which only happens a few times per handshake.
+type keyLogFile string
+
+func (f keyLogFile) Write(p []byte) (int, error) {
+	file, err := os.OpenFile(string(f), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := file.Write(p)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return n, err
+}
+
+// qlogWriter returns the QlogWriter, or one creating files in QlogDir.
+func (c *Config) qlogWriter() func(bool, string) io.WriteCloser {
+	if c.QlogWriter != nil || c.QlogDir == "" {
+		return c.QlogWriter
+	}
+
+	dir := c.QlogDir
+	lf := c.LoggerFactory
+	if lf == nil {
+		lf = logging.NewDefaultLoggerFactory()
+	}
+	log := lf.NewLogger("quic")
+
+	return func(isClient bool, connID string) io.WriteCloser {
+		role := "server"
+		if isClient {
+			role = "client"
+		}
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			log.Warnf("Failed to create qlog directory: %v", err)
+
+			return nil
+		}
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("%s_%s.sqlog", connID, role))) //nolint:gosec // G304
+		if err != nil {
+			log.Warnf("Failed to create qlog file: %v", err)
+
+			return nil
+		}
+
+		return f
+	}
+}
+
+// verifyConnection checks the peer certificate against RemoteFingerprints.
+// Unlike VerifyPeerCertificate, tls.Config.VerifyConnection also runs on
+// resumed sessions and on servers that do not request a certificate.
+func (c *Config) verifyConnection() func(tls.ConnectionState) error {
+	if len(c.RemoteFingerprints) == 0 {
+		return nil
+	}
+
+	fingerprints := append([]Fingerprint(nil), c.RemoteFingerprints...)
+
+	return func(state tls.ConnectionState) error {
+		rawCerts := make([][]byte, 0, len(state.PeerCertificates))
+		for _, cert := range state.PeerCertificates {
+			rawCerts = append(rawCerts, cert.Raw)
+		}
+
+		return verifyFingerprints(rawCerts, fingerprints)
+	}
+}
+
+// checkServer rejects a server Config whose RemoteFingerprints could not
+// be checked, because no client certificate is requested.
+func (c *Config) checkServer() error {
+	if len(c.RemoteFingerprints) > 0 && c.ClientAuth == ClientAuthNone {
+		return ErrFingerprintsWithoutClientAuth
+	}
+
+	return nil
+}
+
+// packetConn adapts conn as configured by FramePackets.
+func (c *Config) packetConn(conn net.Conn) *PacketConn {
+	return newPacketConn(conn, c.FramePackets)
+}
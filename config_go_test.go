@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errRejected = errors.New("rejected")
+
+func generateLocalhostCertificate(t *testing.T) (*x509.Certificate, crypto.PrivateKey) {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(0, 1, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	raw, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return cert, priv
+}
+
+func TestConfig_VerifyServerCertificate(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cert, key := generateLocalhostCertificate(t)
+	listener, err := NewListener("localhost:0", &Config{
+		Certificate: cert,
+		PrivateKey:  key,
+		ClientAuth:  ClientAuthNone,
+	})
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			server, aErr := listener.Accept(context.Background())
+			if aErr != nil {
+				return
+			}
+			server.OnStateChange(func(state TransportState) {
+				if state == TransportStateClosed {
+					assert.NoError(t, server.Stop(TransportStopInfo{}))
+				}
+			})
+		}
+	}()
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(cert)
+
+	client, err := NewTransport(listener.Addr().String(), &Config{
+		RootCAs:    trusted,
+		ServerName: "localhost",
+	})
+	require.NoError(t, err)
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+
+	_, err = NewTransport(listener.Addr().String(), &Config{
+		RootCAs:    x509.NewCertPool(),
+		ServerName: "localhost",
+	})
+	assert.Error(t, err)
+
+	_, err = NewTransport(listener.Addr().String(), &Config{
+		RootCAs:    trusted,
+		ServerName: "example.com",
+	})
+	assert.Error(t, err)
+
+	_, err = NewTransport(listener.Addr().String(), &Config{
+		VerifyPeerCertificate: func([][]byte, [][]*x509.Certificate) error {
+			return errRejected
+		},
+	})
+	assert.Error(t, err)
+
+	assert.NoError(t, listener.Close())
+}
+
+func TestClientAuthType_tlsClientAuth(t *testing.T) {
+	assert.Equal(t, "RequireAnyClientCert", ClientAuthRequireAny.tlsClientAuth().String())
+	assert.Equal(t, "NoClientCert", ClientAuthNone.tlsClientAuth().String())
+	assert.Equal(t, "RequestClientCert", ClientAuthRequest.tlsClientAuth().String())
+	assert.Equal(t, "RequireAndVerifyClientCert", ClientAuthRequireAndVerify.tlsClientAuth().String())
+}
+
+type notifyingBuffer struct {
+	bytes.Buffer
+	closed chan struct{}
+}
+
+func (b *notifyingBuffer) Close() error {
+	close(b.closed)
+
+	return nil
+}
+
+func TestConfig_Qlog(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	dir := filepath.Join(t.TempDir(), "qlog")
+	serverTrace := &notifyingBuffer{closed: make(chan struct{})}
+	client, server, listener := newTransportPair(t, &Config{QlogDir: dir}, &Config{
+		QlogWriter: func(isClient bool, connID string) io.WriteCloser {
+			assert.False(t, isClient)
+			assert.NotEmpty(t, connID)
+
+			return serverTrace
+		},
+	})
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+
+	<-serverTrace.closed
+	assert.Contains(t, serverTrace.String(), `"vantage_point":{"type":"server"}`)
+	assert.Contains(t, serverTrace.String(), "transport:packet_received")
+
+	assert.Eventually(t, func() bool {
+		files, err := filepath.Glob(filepath.Join(dir, "*_client.sqlog"))
+		if err != nil || len(files) != 1 {
+			return false
+		}
+		data, err := os.ReadFile(files[0]) //nolint:gosec // G304
+		if err != nil {
+			return false
+		}
+
+		return strings.Contains(string(data), "transport:packet_sent")
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.buf.String()
+}
+
+func TestConfig_KeyLog(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	path := filepath.Join(t.TempDir(), "keylog.txt")
+	t.Setenv("SSLKEYLOGFILE", path)
+
+	keyLog := &lockedBuffer{}
+	client, server, listener := newTransportPair(t, &Config{KeyLogWriter: keyLog}, &Config{})
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+
+	// The client logs to KeyLogWriter, the server falls back to SSLKEYLOGFILE.
+	assert.Contains(t, keyLog.String(), "CLIENT_HANDSHAKE_TRAFFIC_SECRET ")
+	data, err := os.ReadFile(path) //nolint:gosec // G304
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "SERVER_TRAFFIC_SECRET_0 ")
+}
+
+func TestConfig_NextProtos(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The server's preference wins.
+	client, server, listener := newTransportPair(t,
+		&Config{NextProtos: []string{"custom", "pion-quic"}},
+		&Config{NextProtos: []string{"h3", "pion-quic", "custom"}},
+	)
+	assert.Equal(t, "pion-quic", client.NegotiatedProtocol())
+	assert.Equal(t, "pion-quic", server.NegotiatedProtocol())
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+
+	// Without a shared protocol the handshake fails.
+	cert, key, err := GenerateSelfSigned()
+	require.NoError(t, err)
+	listener, err = NewListener("localhost:0", &Config{
+		Certificate: cert, PrivateKey: key, NextProtos: []string{"h3"},
+	})
+	require.NoError(t, err)
+	_, err = NewTransport(listener.Addr().String(), &Config{
+		Certificate: cert, PrivateKey: key, NextProtos: []string{"custom"},
+	})
+	assert.Error(t, err)
+	assert.NoError(t, listener.Close())
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultDatagramHighWaterMark is the number of outgoing datagrams that are
+// queued when Config.DatagramHighWaterMark is not set.
+const defaultDatagramHighWaterMark = 32
+
+// datagramQueue buffers outgoing datagrams until they are handed to the
+// session. Once highWaterMark datagrams are queued the oldest one is dropped,
+// so the most recent datagrams are the ones that get sent. The session is
+// given one datagram at a time, so that datagrams do not pile up in the
+// QUIC stack's own queue, where they could no longer be dropped.
+type datagramQueue struct {
+	lock          sync.Mutex
+	queue         [][]byte
+	highWaterMark int
+	sent          uint64
+	dropped       uint64
+
+	notEmpty chan struct{}
+	dequeued chan struct{}
+}
+
+func newDatagramQueue(highWaterMark int) *datagramQueue {
+	if highWaterMark <= 0 {
+		highWaterMark = defaultDatagramHighWaterMark
+	}
+
+	return &datagramQueue{
+		highWaterMark: highWaterMark,
+		notEmpty:      make(chan struct{}, 1),
+		dequeued:      make(chan struct{}, 1),
+	}
+}
+
+func (q *datagramQueue) push(data []byte) {
+	q.lock.Lock()
+	if len(q.queue) >= q.highWaterMark {
+		q.queue[0] = nil
+		q.queue = q.queue[1:]
+		q.dropped++
+	}
+	q.queue = append(q.queue, data)
+	q.lock.Unlock()
+
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+}
+
+func (q *datagramQueue) pop() ([]byte, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.queue) == 0 {
+		return nil, false
+	}
+	data := q.queue[0]
+	q.queue[0] = nil
+	q.queue = q.queue[1:]
+
+	select {
+	case q.dequeued <- struct{}{}:
+	default:
+	}
+
+	return data, true
+}
+
+// wait blocks until the queue is below its high-water mark. It fails with the
+// close reason once the session context is done.
+func (q *datagramQueue) wait(ctx, session context.Context) error {
+	for {
+		q.lock.Lock()
+		ready := len(q.queue) < q.highWaterMark
+		q.lock.Unlock()
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-q.dequeued:
+		case <-session.Done():
+			return context.Cause(session)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run hands queued datagrams to send until the session context is done.
+// send blocks until the datagram was sent, and meanwhile further datagrams
+// are queued here.
+func (q *datagramQueue) run(session context.Context, send func([]byte) error) {
+	for {
+		data, ok := q.pop()
+		if !ok {
+			select {
+			case <-q.notEmpty:
+				continue
+			case <-session.Done():
+				return
+			}
+		}
+
+		err := send(data)
+		q.lock.Lock()
+		if err != nil {
+			q.dropped++
+		} else {
+			q.sent++
+		}
+		q.lock.Unlock()
+	}
+}
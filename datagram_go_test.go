@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport_Datagrams(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{EnableDatagrams: true}, &Config{EnableDatagrams: true})
+
+	maxSize := client.MaxDatagramSize()
+	assert.Greater(t, maxSize, 0)
+	assert.ErrorIs(t, client.SendDatagram(make([]byte, maxSize+1)), ErrDatagramTooLarge)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, client.ReadyToSendDatagram(ctx))
+	assert.NoError(t, client.SendDatagram([]byte("ping")))
+
+	data, err := server.ReceiveDatagram(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ping"), data)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestTransport_DatagramsDisabled(t *testing.T) {
+	var base TransportBase
+
+	assert.ErrorIs(t, base.SendDatagram([]byte("ping")), ErrDatagramsDisabled)
+	assert.ErrorIs(t, base.ReadyToSendDatagram(context.Background()), ErrDatagramsDisabled)
+	_, err := base.ReceiveDatagram(context.Background())
+	assert.ErrorIs(t, err, ErrDatagramsDisabled)
+	assert.Equal(t, 0, base.MaxDatagramSize())
+}
+
+func TestDatagramQueue_DropsOldest(t *testing.T) {
+	queue := newDatagramQueue(2)
+	queue.push([]byte{1})
+	queue.push([]byte{2})
+	queue.push([]byte{3})
+
+	first, ok := queue.pop()
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2}, first)
+
+	second, ok := queue.pop()
+	assert.True(t, ok)
+	assert.Equal(t, []byte{3}, second)
+
+	_, ok = queue.pop()
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), queue.dropped)
+}
+
+func TestDatagramQueue_RunOneAtATime(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	queue := newDatagramQueue(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	sending := make(chan []byte)
+	sent := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		queue.run(ctx, func(data []byte) error {
+			sending <- data
+			<-sent
+
+			return nil
+		})
+	}()
+
+	// While the first datagram is being sent, only the latest one is kept.
+	queue.push([]byte{1})
+	assert.Equal(t, []byte{1}, <-sending)
+	queue.push([]byte{2})
+	queue.push([]byte{3})
+	sent <- struct{}{}
+	assert.Equal(t, []byte{3}, <-sending)
+	sent <- struct{}{}
+
+	cancel()
+	<-done
+	assert.Equal(t, uint64(2), queue.sent)
+	assert.Equal(t, uint64(1), queue.dropped)
+}
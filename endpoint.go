@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"net"
+
+	"github.com/pion/logging"
+	"github.com/pion/quic/internal/wrapper"
+)
+
+// Endpoint runs several independent QUIC connections over one net.Conn or
+// net.PacketConn, demultiplexed by connection ID. It both dials and accepts
+// connections, so it suits peer-to-peer use where one path, for example
+// the one selected by ICE, carries several sessions.
+type Endpoint struct {
+	e             *wrapper.Endpoint
+	config        *Config
+	loggerFactory logging.LoggerFactory
+}
+
+// NewEndpoint creates an Endpoint over a connected conn, adapted like in
+// StartBase. The shared conn is not closed by the Endpoint or its
+// Transports.
+func NewEndpoint(conn net.Conn, config *Config) (*Endpoint, error) {
+	return newEndpoint(config, func(cfg *wrapper.Config) (*wrapper.Endpoint, error) {
+		return wrapper.NewEndpoint(config.packetConn(conn).quicConn(), cfg)
+	})
+}
+
+// NewPacketEndpoint creates an Endpoint over conn, for example a UDP
+// socket. The shared conn is not closed by the Endpoint or its Transports.
+func NewPacketEndpoint(conn net.PacketConn, config *Config) (*Endpoint, error) {
+	return newEndpoint(config, func(cfg *wrapper.Config) (*wrapper.Endpoint, error) {
+		return wrapper.NewEndpoint(conn, cfg)
+	})
+}
+
+func newEndpoint(config *Config, create func(*wrapper.Config) (*wrapper.Endpoint, error)) (*Endpoint, error) {
+	if err := config.checkServer(); err != nil {
+		return nil, err
+	}
+
+	loggerFactory := config.LoggerFactory
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+
+	e, err := create(config.clone())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Endpoint{
+		e:             e,
+		config:        config,
+		loggerFactory: loggerFactory,
+	}, nil
+}
+
+// Dial establishes a new Transport to addr. On an Endpoint over a
+// net.Conn, addr is the remote address of that conn.
+func (e *Endpoint) Dial(ctx context.Context, addr net.Addr) (*Transport, error) {
+	s, err := e.e.Dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.start(s)
+}
+
+// Accept waits for and returns the next incoming Transport. Handshakes run
+// concurrently, so a peer that stalls its handshake does not hold up
+// others. With Config.Enable0RTT the Transport is returned before its
+// handshake completed, see Listener.Accept.
+func (e *Endpoint) Accept(ctx context.Context) (*Transport, error) {
+	s, err := e.e.Accept(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.start(s)
+}
+
+func (e *Endpoint) start(s *wrapper.Conn) (*Transport, error) {
+	t := &Transport{}
+	t.TransportBase.log = e.loggerFactory.NewLogger("quic")
+
+	return t, t.TransportBase.startBase(s, e.config)
+}
+
+// LocalAddr returns the local network address of the Endpoint.
+func (e *Endpoint) LocalAddr() net.Addr {
+	return e.e.LocalAddr()
+}
+
+// Close stops accepting new connections and closes the Transports of the
+// Endpoint. Each Transport can also be stopped on its own.
+func (e *Endpoint) Close() error {
+	return e.e.Close()
+}
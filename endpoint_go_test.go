@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/dpipe"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoAccept accepts n Transports which echo their bidirectional streams.
+func echoAccept(t *testing.T, e *Endpoint, n int) <-chan *Transport {
+	t.Helper()
+
+	accepted := make(chan *Transport, n)
+	go func() {
+		defer close(accepted)
+		for i := 0; i < n; i++ {
+			transport, err := e.Accept(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			transport.OnBidirectionalStream(func(stream *BidirectionalStream) {
+				_, cErr := io.Copy(stream.Writer(), stream)
+				assert.NoError(t, cErr)
+				assert.NoError(t, stream.CloseWrite())
+			})
+			accepted <- transport
+		}
+	}()
+
+	return accepted
+}
+
+func assertEcho(t *testing.T, transport *Transport, msg string) {
+	t.Helper()
+
+	stream, err := transport.CreateBidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte(msg), Finished: true}))
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, msg, string(data))
+}
+
+func TestEndpoint_PacketConn(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	connA, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	connB, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	cfgA, cfgB := newP2PConfigs(t)
+	endpointA, err := NewPacketEndpoint(connA, cfgA)
+	require.NoError(t, err)
+	endpointB, err := NewPacketEndpoint(connB, cfgB)
+	require.NoError(t, err)
+	assert.Equal(t, connB.LocalAddr(), endpointB.LocalAddr())
+
+	accepted := echoAccept(t, endpointB, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first, err := endpointA.Dial(ctx, connB.LocalAddr())
+	require.NoError(t, err)
+	second, err := endpointA.Dial(ctx, connB.LocalAddr())
+	require.NoError(t, err)
+	firstRemote, secondRemote := <-accepted, <-accepted
+
+	assertEcho(t, first, "first")
+	assertEcho(t, second, "second")
+
+	// Stopping one Transport leaves the others and the socket running.
+	assert.NoError(t, first.Stop(TransportStopInfo{}))
+	assertEcho(t, second, "still there")
+
+	assert.NoError(t, endpointA.Close())
+	assert.NoError(t, endpointB.Close())
+	for _, transport := range []*Transport{firstRemote, second, secondRemote} {
+		assert.NoError(t, transport.Stop(TransportStopInfo{}))
+	}
+
+	_, err = connA.WriteTo([]byte("not closed"), connB.LocalAddr())
+	assert.NoError(t, err)
+	assert.NoError(t, connA.Close())
+	assert.NoError(t, connB.Close())
+}
+
+func TestEndpoint_Conn(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	connA, connB := dpipe.Pipe()
+	cfgA, cfgB := newP2PConfigs(t)
+	endpointA, err := NewEndpoint(connA, cfgA)
+	require.NoError(t, err)
+	endpointB, err := NewEndpoint(connB, cfgB)
+	require.NoError(t, err)
+
+	// Both sides dial over the same path.
+	acceptedA := echoAccept(t, endpointA, 1)
+	acceptedB := echoAccept(t, endpointB, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	fromA, err := endpointA.Dial(ctx, connA.RemoteAddr())
+	require.NoError(t, err)
+	fromB, err := endpointB.Dial(ctx, connB.RemoteAddr())
+	require.NoError(t, err)
+	remoteA, remoteB := <-acceptedA, <-acceptedB
+
+	assertEcho(t, fromA, "from a")
+	assertEcho(t, fromB, "from b")
+
+	for _, transport := range []*Transport{fromA, fromB, remoteA, remoteB} {
+		assert.NoError(t, transport.Stop(TransportStopInfo{}))
+	}
+	assert.NoError(t, endpointA.Close())
+	assert.NoError(t, endpointB.Close())
+	assert.NoError(t, connA.Close())
+	assert.NoError(t, connB.Close())
+}
+
+func TestEndpoint_StalledHandshake(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	conns := make([]*net.UDPConn, 3)
+	for i := range conns {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		require.NoError(t, err)
+		conns[i] = conn
+	}
+	cfgA, cfgB := newP2PConfigs(t)
+	cfgB.HandshakeIdleTimeout = 10 * time.Second
+	cfgC, _ := newP2PConfigs(t)
+	endpointA, err := NewPacketEndpoint(conns[0], cfgA)
+	require.NoError(t, err)
+	endpointB, err := NewPacketEndpoint(conns[1], cfgB)
+	require.NoError(t, err)
+	endpointC, err := NewPacketEndpoint(conns[2], cfgC)
+	require.NoError(t, err)
+
+	accepted := echoAccept(t, endpointB, 1)
+
+	// The server's packets to C are dropped, so C never completes its
+	// handshake.
+	proxy, upstream := delayProxy(t, conns[1].LocalAddr(), -1)
+	stalledCtx, cancelStalled := context.WithCancel(context.Background())
+	stalled := make(chan error)
+	go func() {
+		_, dErr := endpointC.Dial(stalledCtx, proxy.LocalAddr())
+		stalled <- dErr
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	transport, err := endpointA.Dial(ctx, conns[1].LocalAddr())
+	require.NoError(t, err)
+	select {
+	case remote := <-accepted:
+		assertEcho(t, transport, "not stalled")
+		assert.NoError(t, remote.Stop(TransportStopInfo{}))
+	case err = <-stalled:
+		t.Fatalf("stalled handshake finished first: %v", err)
+	}
+
+	cancelStalled()
+	assert.ErrorIs(t, <-stalled, context.Canceled)
+	assert.NoError(t, transport.Stop(TransportStopInfo{}))
+	for _, e := range []*Endpoint{endpointA, endpointB, endpointC} {
+		assert.NoError(t, e.Close())
+	}
+	assert.NoError(t, proxy.Close())
+	assert.NoError(t, upstream.Close())
+	for _, conn := range conns {
+		assert.NoError(t, conn.Close())
+	}
+}
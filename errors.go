@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"errors"
+	"fmt"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+var (
+	// ErrDatagramsDisabled is returned when datagrams are used on a Transport
+	// that did not enable them, or whose peer did not enable them.
+	ErrDatagramsDisabled = errors.New("quic: datagram support disabled")
+
+	// ErrDatagramTooLarge is returned by SendDatagram when the data exceeds
+	// MaxDatagramSize.
+	ErrDatagramTooLarge = errors.New("quic: datagram too large")
+
+	// ErrNoCertificate is returned when a Config without a certificate is
+	// used where one is required.
+	ErrNoCertificate = errors.New("quic: no certificate")
+
+	// ErrUnsupportedFingerprintAlgorithm is returned when a fingerprint is
+	// computed with an unknown hash function.
+	ErrUnsupportedFingerprintAlgorithm = errors.New("quic: unsupported fingerprint algorithm")
+
+	// ErrNoRemoteCertificate fails the handshake when the peer did not
+	// present a certificate to check against Config.RemoteFingerprints.
+	ErrNoRemoteCertificate = errors.New("quic: peer did not present a certificate")
+
+	// ErrFingerprintMismatch fails the handshake when the peer certificate
+	// matches none of Config.RemoteFingerprints.
+	ErrFingerprintMismatch = errors.New("quic: remote certificate does not match any fingerprint")
+
+	// ErrFingerprintsWithoutClientAuth is returned when a server Config has
+	// RemoteFingerprints but does not request a client certificate.
+	ErrFingerprintsWithoutClientAuth = errors.New("quic: remote fingerprints require client authentication")
+
+	// ErrInvalidErrorCode is returned when an ErrorCode above MaxErrorCode
+	// is used.
+	ErrInvalidErrorCode = errors.New("quic: error code exceeds 2^62-1")
+
+	// ErrWriteBufferFull is returned by QueueWrite when the data does not
+	// fit into the stream's write buffer.
+	ErrWriteBufferFull = errors.New("quic: write buffer full")
+
+	// ErrStreamFinished is returned when data is queued on a stream that
+	// was already finished.
+	ErrStreamFinished = errors.New("quic: stream already finished")
+
+	// ErrUnknownAddress is returned when a PacketConn writes to an address
+	// other than the remote address of its conn.
+	ErrUnknownAddress = errors.New("quic: write to an address other than the remote address")
+
+	// ErrPacketTooLarge is returned when a packet does not fit into a frame
+	// of a stream-oriented PacketConn.
+	ErrPacketTooLarge = errors.New("quic: packet exceeds the maximum frame size")
+
+	// Err0RTTRejected is returned by streams that were opened before the
+	// handshake completed when the server rejected 0-RTT.
+	Err0RTTRejected = quic.Err0RTTRejected
+)
+
+// ErrorCode is a QUIC application error code, as used to stop a Transport
+// or to abort a stream. Its meaning is defined by the application.
+type ErrorCode uint64
+
+// MaxErrorCode is the largest ErrorCode, the maximum value of a QUIC
+// variable-length integer.
+const MaxErrorCode ErrorCode = 1<<62 - 1
+
+func (c ErrorCode) validate() error {
+	if c > MaxErrorCode {
+		return fmt.Errorf("%w: %#x", ErrInvalidErrorCode, uint64(c))
+	}
+
+	return nil
+}
+
+// TransportError is returned when a Transport was stopped with an
+// application error code, either locally or by the peer.
+type TransportError struct {
+	ErrorCode ErrorCode
+	Reason    string
+	// Remote is true if the peer stopped the Transport.
+	Remote bool
+
+	err error
+}
+
+func (e *TransportError) Error() string {
+	side := "locally"
+	if e.Remote {
+		side = "by peer"
+	}
+	if e.Reason == "" {
+		return fmt.Sprintf("quic: transport stopped %s with error code %#x", side, e.ErrorCode)
+	}
+
+	return fmt.Sprintf("quic: transport stopped %s with error code %#x: %s", side, e.ErrorCode, e.Reason)
+}
+
+// Unwrap returns the underlying quic-go error, which matches net.ErrClosed.
+func (e *TransportError) Unwrap() error {
+	return e.err
+}
+
+// StreamError is returned from stream operations after the stream was
+// aborted with an application error code, either locally or by the peer.
+type StreamError struct {
+	StreamID  StreamID
+	ErrorCode ErrorCode
+	// Remote is true if the peer aborted the stream.
+	Remote bool
+	// StopSending is true if the receiving side aborted the stream with a
+	// STOP_SENDING frame, and false if the sending side aborted it with a
+	// RESET_STREAM frame.
+	StopSending bool
+
+	err error
+}
+
+func (e *StreamError) Error() string {
+	side := "locally"
+	if e.Remote {
+		side = "by peer"
+	}
+	frame := "reset"
+	if e.StopSending {
+		frame = "stop sending"
+	}
+
+	return fmt.Sprintf("quic: stream %d aborted %s (%s) with error code %#x", e.StreamID, side, frame, e.ErrorCode)
+}
+
+// Unwrap returns the underlying quic-go error.
+func (e *StreamError) Unwrap() error {
+	return e.err
+}
+
+// wrapError converts quic-go errors into the error types of this package.
+func wrapError(err error) error {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) {
+		return &TransportError{
+			ErrorCode: ErrorCode(appErr.ErrorCode),
+			Reason:    appErr.ErrorMessage,
+			Remote:    appErr.Remote,
+			err:       err,
+		}
+	}
+
+	return err
+}
+
+// wrapStreamError converts quic-go stream errors into a StreamError. sendSide
+// is true if err was returned by the sending side of the stream.
+func wrapStreamError(err error, sendSide bool) error {
+	var streamErr *quic.StreamError
+	if errors.As(err, &streamErr) {
+		return &StreamError{
+			StreamID:  StreamID(streamErr.StreamID),
+			ErrorCode: ErrorCode(streamErr.ErrorCode),
+			Remote:    streamErr.Remote,
+			// A remote abort of the sending side and a local abort of the
+			// receiving side both come from STOP_SENDING.
+			StopSending: sendSide == streamErr.Remote,
+			err:         err,
+		}
+	}
+
+	return wrapError(err)
+}
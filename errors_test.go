@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapError(t *testing.T) {
+	err := wrapError(&quic.ApplicationError{Remote: true, ErrorCode: 42, ErrorMessage: "bye"})
+
+	var transportErr *TransportError
+	assert.True(t, errors.As(err, &transportErr))
+	assert.Equal(t, ErrorCode(42), transportErr.ErrorCode)
+	assert.Equal(t, "bye", transportErr.Reason)
+	assert.True(t, transportErr.Remote)
+	assert.ErrorIs(t, err, net.ErrClosed)
+	assert.Equal(t, "quic: transport stopped by peer with error code 0x2a: bye", err.Error())
+
+	assert.Nil(t, wrapError(nil))
+	assert.Equal(t, io.EOF, wrapError(io.EOF))
+}
+
+func TestWrapStreamError(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		remote      bool
+		sendSide    bool
+		stopSending bool
+	}{
+		{"peer reset", true, false, false},
+		{"peer stop sending", true, true, true},
+		{"local reset", false, true, false},
+		{"local stop sending", false, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := wrapStreamError(&quic.StreamError{StreamID: 4, ErrorCode: 7, Remote: test.remote}, test.sendSide)
+
+			var streamErr *StreamError
+			assert.True(t, errors.As(err, &streamErr))
+			assert.Equal(t, StreamID(4), streamErr.StreamID)
+			assert.Equal(t, ErrorCode(7), streamErr.ErrorCode)
+			assert.Equal(t, test.remote, streamErr.Remote)
+			assert.Equal(t, test.stopSending, streamErr.StopSending)
+		})
+	}
+
+	var transportErr *TransportError
+	assert.True(t, errors.As(wrapStreamError(&quic.ApplicationError{}, false), &transportErr))
+}
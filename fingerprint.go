@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// Fingerprint specifies the hash function algorithm and certificate
+// fingerprint as described in RFC 8122, following RTCDtlsFingerprint.
+type Fingerprint struct {
+	// Algorithm is the name of the hash function, e.g. "sha-256".
+	Algorithm string
+	// Value is the hex encoded fingerprint with colon separated bytes.
+	Value string
+}
+
+func fingerprintHash(algorithm string) (crypto.Hash, bool) {
+	switch strings.ToLower(algorithm) {
+	case "sha-224":
+		return crypto.SHA224, true
+	case "sha-256":
+		return crypto.SHA256, true
+	case "sha-384":
+		return crypto.SHA384, true
+	case "sha-512":
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// CertificateFingerprint computes the fingerprint of cert with the given
+// hash function algorithm.
+func CertificateFingerprint(cert *x509.Certificate, algorithm string) (Fingerprint, error) {
+	hash, ok := fingerprintHash(algorithm)
+	if !ok {
+		return Fingerprint{}, fmt.Errorf("%w: %s", ErrUnsupportedFingerprintAlgorithm, algorithm)
+	}
+
+	return Fingerprint{
+		Algorithm: strings.ToLower(algorithm),
+		Value:     fingerprintValue(hash, cert.Raw),
+	}, nil
+}
+
+func fingerprintValue(hash crypto.Hash, raw []byte) string {
+	h := hash.New()
+	_, _ = h.Write(raw) // Writing to a hash never fails
+
+	digest := h.Sum(nil)
+	parts := make([]string, len(digest))
+	for i, b := range digest {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// verifyFingerprints checks that the leaf certificate matches one of the
+// fingerprints. Fingerprints with unsupported algorithms are ignored.
+func verifyFingerprints(rawCerts [][]byte, fingerprints []Fingerprint) error {
+	if len(rawCerts) == 0 {
+		return ErrNoRemoteCertificate
+	}
+
+	for _, fp := range fingerprints {
+		hash, ok := fingerprintHash(fp.Algorithm)
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(fingerprintValue(hash, rawCerts[0]), fp.Value) {
+			return nil
+		}
+	}
+
+	return ErrFingerprintMismatch
+}
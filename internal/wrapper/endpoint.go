@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package wrapper
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// Endpoint runs several QUIC connections over one PacketConn. quic-go
+// demultiplexes the packets by connection ID.
+type Endpoint struct {
+	tr         *quic.Transport
+	l          *Listener
+	tlsConfig  *tls.Config
+	quicConfig *quic.Config
+	enable0RTT bool
+}
+
+// NewEndpoint creates an Endpoint that dials and accepts connections over
+// conn. Closing the Endpoint does not close conn.
+func NewEndpoint(conn net.PacketConn, config *Config) (*Endpoint, error) {
+	e := &Endpoint{
+		tr:         &quic.Transport{Conn: conn},
+		tlsConfig:  getTLSConfig(config),
+		quicConfig: getQuicConfig(config),
+		enable0RTT: config.Enable0RTT,
+	}
+	// As in Listen, an early listener is only used for 0-RTT.
+	var l quicListener
+	var err error
+	if config.Enable0RTT {
+		l, err = e.tr.ListenEarly(e.tlsConfig, e.quicConfig)
+	} else {
+		l, err = e.tr.Listen(e.tlsConfig, e.quicConfig)
+	}
+	if err != nil {
+		_ = e.tr.Close()
+
+		return nil, err
+	}
+	e.l = &Listener{l: l}
+
+	return e, nil
+}
+
+// Dial establishes a new connection to addr.
+func (e *Endpoint) Dial(ctx context.Context, addr net.Addr) (*Conn, error) {
+	dial := e.tr.Dial
+	if e.enable0RTT {
+		dial = e.tr.DialEarly
+	}
+	c, err := dial(ctx, addr, e.tlsConfig, e.quicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return newConn(c), nil
+}
+
+// Accept accepts an incoming connection. With 0-RTT it is returned before
+// its handshake completed.
+func (e *Endpoint) Accept(ctx context.Context) (*Conn, error) {
+	return e.l.Accept(ctx)
+}
+
+// LocalAddr returns the local address of the Endpoint.
+func (e *Endpoint) LocalAddr() net.Addr {
+	return e.l.Addr()
+}
+
+// Close closes all connections of the Endpoint.
+func (e *Endpoint) Close() error {
+	if err := e.l.Close(); err != nil {
+		_ = e.tr.Close()
+
+		return err
+	}
+
+	return e.tr.Close()
+}
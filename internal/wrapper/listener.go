@@ -5,23 +5,54 @@ package wrapper
 
 import (
 	"context"
+	"net"
 
 	quic "github.com/quic-go/quic-go"
 )
 
+// quicListener is implemented by quic.Listener and quic.EarlyListener.
+type quicListener interface {
+	Accept(ctx context.Context) (*quic.Conn, error)
+	Addr() net.Addr
+	Close() error
+}
+
 // A Listener for incoming QUIC connections.
 type Listener struct {
-	l *quic.Listener
+	l quicListener
+	// waitHandshake makes Accept wait for the handshake of connections
+	// returned early, to report handshake failures to the caller.
+	waitHandshake bool
 }
 
-// Accept accepts incoming streams.
-func (l *Listener) Accept() (*Conn, error) {
-	c, err := l.l.Accept(context.TODO())
+// Accept accepts incoming connections. With an early listener, the
+// connection is returned before its handshake completed unless
+// waitHandshake is set.
+func (l *Listener) Accept(ctx context.Context) (*Conn, error) {
+	c, err := l.l.Accept(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if !l.waitHandshake {
+		return newConn(c), nil
+	}
+
+	select {
+	case <-c.HandshakeComplete():
+	case <-c.Context().Done():
+		return nil, context.Cause(c.Context())
+	case <-ctx.Done():
+		_ = c.CloseWithError(0, ctx.Err().Error())
+
+		return nil, ctx.Err()
+	}
+
+	return newConn(c), nil
+}
 
-	return &Conn{c: c}, nil
+// Addr returns the local network address that the listener is listening on.
+func (l *Listener) Addr() net.Addr {
+	return l.l.Addr()
 }
 
 // Close closes the listener.
@@ -13,7 +13,7 @@ import (
 	"errors"
 	"io"
 	"net"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/quic-go/quic-go"
@@ -24,6 +24,38 @@ type Config struct {
 	Certificate *x509.Certificate
 	PrivateKey  crypto.PrivateKey
 	SkipVerify  bool
+
+	RootCAs               *x509.CertPool
+	ServerName            string
+	ClientAuth            tls.ClientAuthType
+	ClientCAs             *x509.CertPool
+	VerifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+	VerifyConnection      func(tls.ConnectionState) error
+	KeyLogWriter          io.Writer
+	NextProtos            []string
+	ClientSessionCache    tls.ClientSessionCache
+
+	Enable0RTT bool
+	Accept0RTT func(remoteAddr net.Addr, addrVerified bool) bool
+
+	EnableDatagrams bool
+
+	MaxIdleTimeout       time.Duration
+	HandshakeIdleTimeout time.Duration
+	KeepAlivePeriod      time.Duration
+
+	InitialStreamReceiveWindow     uint64
+	MaxStreamReceiveWindow         uint64
+	InitialConnectionReceiveWindow uint64
+	MaxConnectionReceiveWindow     uint64
+
+	MaxIncomingStreams    int64
+	MaxIncomingUniStreams int64
+
+	InitialPacketSize uint16
+
+	// QlogWriter returns the destination of a connection's qlog, or nil.
+	QlogWriter func(isClient bool, connID string) io.WriteCloser
 }
 
 func getDefaultQuicConfig() *quic.Config {
@@ -36,46 +68,139 @@ func getDefaultQuicConfig() *quic.Config {
 	}
 }
 
+// getQuicConfig applies the non-zero values of config to the defaults.
+func getQuicConfig(config *Config) *quic.Config {
+	qc := getDefaultQuicConfig()
+	qc.EnableDatagrams = config.EnableDatagrams
+	qc.MaxIdleTimeout = config.MaxIdleTimeout
+	qc.HandshakeIdleTimeout = config.HandshakeIdleTimeout
+	qc.InitialStreamReceiveWindow = config.InitialStreamReceiveWindow
+	qc.InitialConnectionReceiveWindow = config.InitialConnectionReceiveWindow
+	qc.InitialPacketSize = config.InitialPacketSize
+	qc.Tracer = newTracer(config.QlogWriter)
+	qc.Allow0RTT = config.Enable0RTT
+	if accept := config.Accept0RTT; config.Enable0RTT && accept != nil {
+		qc.GetConfigForClient = func(info *quic.ClientInfo) (*quic.Config, error) {
+			conf := qc.Clone()
+			conf.Allow0RTT = accept(info.RemoteAddr, info.AddrVerified)
+
+			return conf, nil
+		}
+	}
+
+	switch {
+	case config.KeepAlivePeriod < 0:
+		qc.KeepAlivePeriod = 0
+	case config.KeepAlivePeriod > 0:
+		qc.KeepAlivePeriod = config.KeepAlivePeriod
+	}
+	if config.MaxStreamReceiveWindow != 0 {
+		qc.MaxStreamReceiveWindow = config.MaxStreamReceiveWindow
+	}
+	if config.MaxConnectionReceiveWindow != 0 {
+		qc.MaxConnectionReceiveWindow = config.MaxConnectionReceiveWindow
+	}
+	if config.MaxIncomingStreams != 0 {
+		qc.MaxIncomingStreams = config.MaxIncomingStreams
+	}
+	if config.MaxIncomingUniStreams != 0 {
+		qc.MaxIncomingUniStreams = config.MaxIncomingUniStreams
+	}
+
+	return qc
+}
+
+// defaultNextProto is the ALPN protocol used when none is configured.
+const defaultNextProto = "pion-quic"
+
+// datagramProbeSize exceeds the maximum size of a QUIC packet.
+const datagramProbeSize = 1 << 11
+
 var errClientWithoutRemoteAddress = errors.New("quic: creating client without remote address")
 
-// Client establishes a QUIC session over an existing conn.
-func Client(ctx context.Context, conn net.Conn, config *Config) (*Conn, error) {
-	rAddr := conn.RemoteAddr()
+// Client establishes a QUIC session to rAddr over an existing conn.
+func Client(ctx context.Context, conn net.PacketConn, rAddr net.Addr, config *Config) (*Conn, error) {
 	if rAddr == nil {
 		return nil, errClientWithoutRemoteAddress
 	}
 
-	c, err := quic.Dial(ctx, newFakePacketConn(conn), rAddr, getTLSConfig(config), getDefaultQuicConfig())
+	dial := quic.Dial
+	if config.Enable0RTT {
+		dial = quic.DialEarly
+	}
+	c, err := dial(ctx, conn, rAddr, getTLSConfig(config), getQuicConfig(config))
 	if err != nil {
 		return nil, err
 	}
 
-	return &Conn{c: c}, nil
+	return newConn(c), nil
 }
 
 // Dial dials the address over quic.
 func Dial(ctx context.Context, addr string, config *Config) (*Conn, error) {
-	c, err := quic.DialAddr(ctx, addr, getTLSConfig(config), getDefaultQuicConfig())
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	udpConn, err := net.ListenUDP("udp", nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Conn{c: c}, nil
+	// The connection gets its own Transport, so that paths over other
+	// sockets can be added to it, see AddPath.
+	tr := &quic.Transport{Conn: udpConn}
+	tlsConfig := getTLSConfig(config)
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+	dial := tr.Dial
+	if config.Enable0RTT {
+		dial = tr.DialEarly
+	}
+	c, err := dial(ctx, udpAddr, tlsConfig, getQuicConfig(config))
+	if err != nil {
+		_ = tr.Close()
+		_ = udpConn.Close()
+
+		return nil, err
+	}
+
+	conn := newConn(c)
+	conn.closeWithConn(udpConn)
+	conn.closeWithConn(tr)
+
+	return conn, nil
 }
 
 // Server creates a listener for listens for incoming QUIC sessions.
-func Server(conn net.Conn, config *Config) (*Listener, error) {
-	l, err := quic.Listen(newFakePacketConn(conn), getTLSConfig(config), getDefaultQuicConfig())
+// Since conn carries a single session, the listener uses early accept so
+// that Accept fails when the handshake of that session fails. With 0-RTT,
+// Accept returns right away so that 0-RTT data is served early.
+func Server(conn net.PacketConn, config *Config) (*Listener, error) {
+	l, err := quic.ListenEarly(conn, getTLSConfig(config), getQuicConfig(config))
 	if err != nil {
 		return nil, err
 	}
 
-	return &Listener{l: l}, nil
+	return &Listener{l: l, waitHandshake: !config.Enable0RTT}, nil
 }
 
 // Listen listens on the address over quic.
+// 0-RTT needs an early listener, whose Accept returns connections before
+// their handshake completed.
 func Listen(addr string, config *Config) (*Listener, error) {
-	l, err := quic.ListenAddr(addr, getTLSConfig(config), getDefaultQuicConfig())
+	var l quicListener
+	var err error
+	if config.Enable0RTT {
+		l, err = quic.ListenAddrEarly(addr, getTLSConfig(config), getQuicConfig(config))
+	} else {
+		l, err = quic.ListenAddr(addr, getTLSConfig(config), getQuicConfig(config))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -85,26 +210,116 @@ func Listen(addr string, config *Config) (*Listener, error) {
 
 func getTLSConfig(config *Config) *tls.Config {
 	/* #nosec G402 */
-	return &tls.Config{
-		MinVersion:         tls.VersionTLS13,
-		InsecureSkipVerify: config.SkipVerify,
-		ClientAuth:         tls.RequireAnyClientCert,
-		Certificates: []tls.Certificate{{
+	tlsConfig := &tls.Config{
+		MinVersion:            tls.VersionTLS13,
+		InsecureSkipVerify:    config.SkipVerify,
+		RootCAs:               config.RootCAs,
+		ServerName:            config.ServerName,
+		ClientAuth:            config.ClientAuth,
+		ClientCAs:             config.ClientCAs,
+		VerifyPeerCertificate: config.VerifyPeerCertificate,
+		VerifyConnection:      config.VerifyConnection,
+		KeyLogWriter:          config.KeyLogWriter,
+		NextProtos:            config.NextProtos,
+		ClientSessionCache:    config.ClientSessionCache,
+	}
+	if len(tlsConfig.NextProtos) == 0 {
+		tlsConfig.NextProtos = []string{defaultNextProto}
+	}
+	if config.Certificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{{
 			Certificate: [][]byte{config.Certificate.Raw},
 			PrivateKey:  config.PrivateKey,
-		}},
-		NextProtos: []string{"pion-quic"},
+		}}
 	}
+
+	return tlsConfig
 }
 
 // A Conn is a QUIC connection between two peers.
 type Conn struct {
 	c *quic.Conn
+
+	lock    sync.Mutex
+	closers []io.Closer
+	closed  bool
+	// datagramLimit caches MaxDatagramSize for the MTU estimate
+	// datagramLimitMTU.
+	datagramLimit    int
+	datagramLimitMTU int
+}
+
+func newConn(c *quic.Conn) *Conn {
+	if trace, ok := c.QlogTrace().(*metricsTrace); ok {
+		trace.conn.Store(c)
+	}
+
+	return &Conn{c: c}
+}
+
+// closeWithConn closes cl once the connection is closed. Closers run in
+// reverse order.
+func (c *Conn) closeWithConn(cl io.Closer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		_ = cl.Close()
+
+		return
+	}
+	c.closers = append(c.closers, cl)
+	if len(c.closers) == 1 {
+		go c.closeOnDone()
+	}
+}
+
+func (c *Conn) closeOnDone() {
+	<-c.c.Context().Done()
+
+	c.lock.Lock()
+	closers := c.closers
+	c.closers = nil
+	c.closed = true
+	c.lock.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		_ = closers[i].Close()
+	}
+}
+
+// AddPath adds a path over conn, which can be probed and switched to. The
+// path stops using conn once the connection is closed, but conn is not
+// closed. Only clients can add paths.
+func (c *Conn) AddPath(conn net.PacketConn) (*quic.Path, error) {
+	tr := &quic.Transport{Conn: conn}
+	path, err := c.c.AddPath(tr)
+	if err != nil {
+		return nil, err
+	}
+	c.closeWithConn(tr)
+
+	return path, nil
 }
 
-// OpenStream opens a new stream.
+// OnRemoteAddressChange sets a handler that is started on its own goroutine
+// when the peer's address changes, for example after a NAT rebinding or a
+// migration.
+func (c *Conn) OnRemoteAddressChange(f func(net.Addr)) {
+	if trace, ok := c.c.QlogTrace().(*metricsTrace); ok {
+		trace.setRemoteAddrHandler(f)
+	}
+}
+
+// OpenStream opens a new stream. It fails with quic.Err0RTTRejected while
+// the handshake after a rejected 0-RTT attempt is in progress.
 func (c *Conn) OpenStream() (*Stream, error) {
 	str, err := c.c.OpenStream()
+	if errors.Is(err, quic.Err0RTTRejected) && c.handshakeCompleted() {
+		if err = c.after0RTTRejected(context.TODO()); err == nil {
+			str, err = c.c.OpenStream()
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +330,11 @@ func (c *Conn) OpenStream() (*Stream, error) {
 // OpenUniStream opens and returns a new WritableStream.
 func (c *Conn) OpenUniStream() (*WritableStream, error) {
 	str, err := c.c.OpenUniStream()
+	if errors.Is(err, quic.Err0RTTRejected) && c.handshakeCompleted() {
+		if err = c.after0RTTRejected(context.TODO()); err == nil {
+			str, err = c.c.OpenUniStream()
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -122,15 +342,48 @@ func (c *Conn) OpenUniStream() (*WritableStream, error) {
 	return &WritableStream{s: str}, nil
 }
 
-// AcceptStream accepts an incoming stream.
-func (c *Conn) AcceptStream() (*Stream, error) {
-	str, err := c.c.AcceptStream(context.TODO())
+// OpenStreamSync opens a new stream, blocking until the peer allows it or
+// ctx is done.
+func (c *Conn) OpenStreamSync(ctx context.Context) (*Stream, error) {
+	str, err := c.c.OpenStreamSync(ctx)
+	if errors.Is(err, quic.Err0RTTRejected) {
+		if err = c.after0RTTRejected(ctx); err == nil {
+			str, err = c.c.OpenStreamSync(ctx)
+		}
+	}
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "Application error 0x0") {
-			//nolint:nilnil // todo fix.
-			return nil, nil // Errorcode == 0 implies session is closed without error
+		return nil, err
+	}
+
+	return &Stream{s: str}, nil
+}
+
+// OpenUniStreamSync opens a new WritableStream, blocking until the peer
+// allows it or ctx is done.
+func (c *Conn) OpenUniStreamSync(ctx context.Context) (*WritableStream, error) {
+	str, err := c.c.OpenUniStreamSync(ctx)
+	if errors.Is(err, quic.Err0RTTRejected) {
+		if err = c.after0RTTRejected(ctx); err == nil {
+			str, err = c.c.OpenUniStreamSync(ctx)
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &WritableStream{s: str}, nil
+}
 
+// AcceptStream accepts an incoming stream. If the server rejected 0-RTT it
+// continues once the handshake completed.
+func (c *Conn) AcceptStream() (*Stream, error) {
+	str, err := c.c.AcceptStream(context.TODO())
+	if errors.Is(err, quic.Err0RTTRejected) {
+		if err = c.after0RTTRejected(context.TODO()); err == nil {
+			str, err = c.c.AcceptStream(context.TODO())
+		}
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -138,33 +391,175 @@ func (c *Conn) AcceptStream() (*Stream, error) {
 }
 
 // AcceptUniStream accepts an incoming unidirectional stream and returns a ReadableStream.
+// If the server rejected 0-RTT it continues once the handshake completed.
 func (c *Conn) AcceptUniStream() (*ReadableStream, error) {
 	str, err := c.c.AcceptUniStream(context.TODO())
-	if err != nil {
-		if strings.HasPrefix(err.Error(), "Application error 0x0") {
-			//nolint:nilnil // todo fix.
-			return nil, nil // Errorcode == 0 implies session is closed without error
+	if errors.Is(err, quic.Err0RTTRejected) {
+		if err = c.after0RTTRejected(context.TODO()); err == nil {
+			str, err = c.c.AcceptUniStream(context.TODO())
 		}
-
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	return &ReadableStream{s: str}, nil
 }
 
+// after0RTTRejected waits for the handshake after the server rejected 0-RTT,
+// so that streams can be used again. Streams opened before are lost.
+func (c *Conn) after0RTTRejected(ctx context.Context) error {
+	if _, err := c.c.NextConnection(ctx); err != nil {
+		return err
+	}
+	if c.c.Context().Err() != nil {
+		return context.Cause(c.c.Context())
+	}
+
+	return nil
+}
+
+func (c *Conn) handshakeCompleted() bool {
+	select {
+	case <-c.c.HandshakeComplete():
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsDatagrams returns true if both peers enabled datagram support.
+func (c *Conn) SupportsDatagrams() bool {
+	return c.c.ConnectionState().SupportsDatagrams
+}
+
+// SendDatagram queues p to be sent in an unreliable QUIC datagram.
+func (c *Conn) SendDatagram(p []byte) error {
+	return c.c.SendDatagram(p)
+}
+
+// SendDatagramPaced queues p like SendDatagram, then waits until the next
+// packet that can carry it was sent, or ctx is done. quic-go queues up to 32
+// datagrams without blocking and sends them in order; pacing keeps that
+// queue short, so the caller can drop stale datagrams instead.
+func (c *Conn) SendDatagramPaced(ctx context.Context, p []byte) error {
+	trace, ok := c.c.QlogTrace().(*metricsTrace)
+	if !ok {
+		return c.c.SendDatagram(p)
+	}
+
+	sent := trace.nextPacketSent()
+	if err := c.c.SendDatagram(p); err != nil {
+		return err
+	}
+	select {
+	case <-sent:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReceiveDatagram blocks until a QUIC datagram is received or ctx is done.
+func (c *Conn) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	return c.c.ReceiveDatagram(ctx)
+}
+
+// MaxDatagramSize returns the largest datagram payload that can currently be
+// sent, or 0 if datagrams are not supported on this connection.
+//
+// quic-go only reports the limit in the error for a datagram that is too
+// large, which it checks before queueing the datagram. So the limit is
+// probed with a datagram larger than any QUIC packet, and cached until the
+// MTU estimate changes.
+func (c *Conn) MaxDatagramSize() int {
+	mtu := 0
+	if trace, ok := c.c.QlogTrace().(*metricsTrace); ok {
+		mtu = trace.getMTU()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.datagramLimit > 0 && c.datagramLimitMTU == mtu {
+		return c.datagramLimit
+	}
+
+	var tooLarge *quic.DatagramTooLargeError
+	if err := c.c.SendDatagram(make([]byte, datagramProbeSize)); !errors.As(err, &tooLarge) {
+		return 0
+	}
+	limit := int(tooLarge.MaxDatagramPayloadSize)
+	// The peer's limit is only known for sure once the handshake completed.
+	if c.handshakeCompleted() {
+		c.datagramLimit, c.datagramLimitMTU = limit, mtu
+	}
+
+	return limit
+}
+
+// Context returns a context that is cancelled when the connection is closed.
+func (c *Conn) Context() context.Context {
+	return c.c.Context()
+}
+
+// HandshakeComplete is closed once the handshake completed. Connections
+// dialed with 0-RTT are returned before that.
+func (c *Conn) HandshakeComplete() <-chan struct{} {
+	return c.c.HandshakeComplete()
+}
+
+// DidResume reports whether the TLS session was resumed.
+func (c *Conn) DidResume() bool {
+	return c.c.ConnectionState().TLS.DidResume
+}
+
+// Used0RTT reports whether 0-RTT data was accepted by the server.
+func (c *Conn) Used0RTT() bool {
+	return c.c.ConnectionState().Used0RTT
+}
+
+// NegotiatedProtocol returns the application protocol selected by ALPN.
+func (c *Conn) NegotiatedProtocol() string {
+	return c.c.ConnectionState().TLS.NegotiatedProtocol
+}
+
+// LocalAddr returns the local address of the connection.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.c.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the connection.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.c.RemoteAddr()
+}
+
+// ConnectionStats returns the statistics of the connection.
+func (c *Conn) ConnectionStats() quic.ConnectionStats {
+	return c.c.ConnectionStats()
+}
+
+// CongestionWindow returns the current congestion window in bytes.
+func (c *Conn) CongestionWindow() uint64 {
+	if trace, ok := c.c.QlogTrace().(*metricsTrace); ok {
+		return trace.getCongestionWindow()
+	}
+
+	return 0
+}
+
 // GetRemoteCertificates returns the certificate chain presented by remote peer.
 func (c *Conn) GetRemoteCertificates() []*x509.Certificate {
 	return c.c.ConnectionState().TLS.PeerCertificates
 }
 
-// Close the connection.
+// Close the connection without an error.
 func (c *Conn) Close() error {
-	return c.c.CloseWithError(0, io.EOF.Error())
+	return c.c.CloseWithError(0, "")
 }
 
 // CloseWithError closes the connection with an error.
 // The error must not be nil.
-func (c *Conn) CloseWithError(code uint16, err error) error {
+func (c *Conn) CloseWithError(code uint64, err error) error {
 	e := "nil"
 	if err != nil {
 		e = err.Error()
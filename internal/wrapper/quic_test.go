@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package wrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetQuicConfig(t *testing.T) {
+	defaults := getQuicConfig(&Config{})
+	assert.NotNil(t, defaults.Tracer)
+	defaults.Tracer = nil
+	assert.Equal(t, getDefaultQuicConfig(), defaults)
+
+	qc := getQuicConfig(&Config{
+		MaxIdleTimeout:             time.Minute,
+		HandshakeIdleTimeout:       time.Second,
+		KeepAlivePeriod:            -1,
+		InitialStreamReceiveWindow: 1 << 10,
+		MaxStreamReceiveWindow:     1 << 20,
+		MaxConnectionReceiveWindow: 1 << 21,
+		MaxIncomingStreams:         -1,
+		MaxIncomingUniStreams:      10,
+		InitialPacketSize:          1350,
+	})
+	assert.Equal(t, time.Minute, qc.MaxIdleTimeout)
+	assert.Equal(t, time.Second, qc.HandshakeIdleTimeout)
+	assert.Equal(t, time.Duration(0), qc.KeepAlivePeriod)
+	assert.Equal(t, uint64(1<<10), qc.InitialStreamReceiveWindow)
+	assert.Equal(t, uint64(1<<20), qc.MaxStreamReceiveWindow)
+	assert.Equal(t, uint64(1<<21), qc.MaxConnectionReceiveWindow)
+	assert.Equal(t, int64(-1), qc.MaxIncomingStreams)
+	assert.Equal(t, int64(10), qc.MaxIncomingUniStreams)
+	assert.Equal(t, uint16(1350), qc.InitialPacketSize)
+}
@@ -46,6 +46,11 @@ func (s *ReadableStream) StreamID() int64 {
 	return int64(s.s.StreamID())
 }
 
+// CancelRead aborts receiving on the stream with a STOP_SENDING frame.
+func (s *ReadableStream) CancelRead(code uint64) {
+	s.s.CancelRead(quic.StreamErrorCode(code))
+}
+
 // SetReadDeadline sets the deadline for future Read calls. A zero value for t means Read will not time out.
 func (s *ReadableStream) SetReadDeadline(t time.Time) error {
 	return s.s.SetReadDeadline(t)
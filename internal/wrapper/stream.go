@@ -4,6 +4,7 @@
 package wrapper
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net"
@@ -70,12 +71,38 @@ func (s *Stream) Close() error {
 	return s.s.Close()
 }
 
+// Context is canceled once the sending side of the stream is closed, reset
+// or stopped, or the connection is closed.
+func (s *Stream) Context() context.Context {
+	return s.s.Context()
+}
+
+// CancelWrite aborts sending on the stream with a RESET_STREAM frame.
+func (s *Stream) CancelWrite(code uint64) {
+	s.s.CancelWrite(quic.StreamErrorCode(code))
+}
+
+// CancelRead aborts receiving on the stream with a STOP_SENDING frame.
+func (s *Stream) CancelRead(code uint64) {
+	s.s.CancelRead(quic.StreamErrorCode(code))
+}
+
 // SetDeadline sets read and write deadlines associated with the stream.
 // A zero value for t means Read and Write will not timeout.
 func (s *Stream) SetDeadline(t time.Time) error {
 	return s.s.SetDeadline(t)
 }
 
+// SetReadDeadline sets the deadline for future Read calls.
+func (s *Stream) SetReadDeadline(t time.Time) error {
+	return s.s.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future Write calls.
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	return s.s.SetWriteDeadline(t)
+}
+
 // Detach returns the underlying quic-go Stream.
 func (s *Stream) Detach() *quic.Stream {
 	return s.s
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package wrapper
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	quic "github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/qlog"
+	"github.com/quic-go/quic-go/qlogwriter"
+)
+
+// metricsTrace is a qlog trace that keeps the metrics quic-go does not
+// expose through ConnectionStats, tracks sent packets for datagram pacing,
+// and watches the remote address of conn as packets are sent and received.
+// Events are passed on to next, if set.
+type metricsTrace struct {
+	next qlogwriter.Trace
+	conn atomic.Pointer[quic.Conn]
+
+	lock              sync.Mutex
+	congestionWindow  uint64
+	mtu               int
+	packetSent        chan struct{}
+	remoteAddr        net.Addr
+	remoteAddrHandler func(net.Addr)
+}
+
+// newTracer returns a quic.Config Tracer which also writes a qlog to the
+// destination returned by qlogWriter.
+func newTracer(
+	qlogWriter func(isClient bool, connID string) io.WriteCloser,
+) func(context.Context, bool, quic.ConnectionID) qlogwriter.Trace {
+	return func(_ context.Context, isClient bool, connID quic.ConnectionID) qlogwriter.Trace {
+		trace := &metricsTrace{}
+		if qlogWriter == nil {
+			return trace
+		}
+		if w := qlogWriter(isClient, connID.String()); w != nil {
+			seq := qlogwriter.NewConnectionFileSeq(newBufferedWriteCloser(w), isClient, connID, []string{qlog.EventSchema})
+			go seq.Run()
+			trace.next = seq
+		}
+
+		return trace
+	}
+}
+
+func (t *metricsTrace) AddProducer() qlogwriter.Recorder {
+	r := metricsRecorder{t: t}
+	if t.next != nil {
+		r.next = t.next.AddProducer()
+	}
+
+	return r
+}
+
+func (t *metricsTrace) SupportsSchemas(schema string) bool {
+	return t.next != nil && t.next.SupportsSchemas(schema)
+}
+
+func (t *metricsTrace) getCongestionWindow() uint64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.congestionWindow
+}
+
+func (t *metricsTrace) getMTU() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.mtu
+}
+
+// nextPacketSent returns a channel that is closed once the next 0-RTT or
+// 1-RTT packet, the packets that can carry datagrams, was sent.
+func (t *metricsTrace) nextPacketSent() <-chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.packetSent == nil {
+		t.packetSent = make(chan struct{})
+	}
+
+	return t.packetSent
+}
+
+func (t *metricsTrace) onPacketSent(packetType qlog.PacketType) {
+	if packetType != qlog.PacketType0RTT && packetType != qlog.PacketType1RTT {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.packetSent != nil {
+		close(t.packetSent)
+		t.packetSent = nil
+	}
+}
+
+func (t *metricsTrace) setRemoteAddrHandler(f func(net.Addr)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.remoteAddrHandler = f
+	if conn := t.conn.Load(); conn != nil && t.remoteAddr == nil {
+		t.remoteAddr = conn.RemoteAddr()
+	}
+}
+
+// checkRemoteAddr starts the handler if the remote address changed.
+func (t *metricsTrace) checkRemoteAddr() {
+	conn := t.conn.Load()
+	if conn == nil {
+		return
+	}
+	addr := conn.RemoteAddr()
+
+	t.lock.Lock()
+	f := t.remoteAddrHandler
+	changed := t.remoteAddr != nil && !sameAddr(t.remoteAddr, addr)
+	t.remoteAddr = addr
+	t.lock.Unlock()
+
+	// This runs on quic-go's run loop, which closing the connection waits
+	// for, so the handler must not block it.
+	if changed && f != nil {
+		go f(addr)
+	}
+}
+
+// sameAddr compares addresses. quic-go replaces the address on a change,
+// so comparing them directly avoids formatting them for each packet.
+func sameAddr(a, b net.Addr) bool {
+	if a == b {
+		return true
+	}
+
+	return a.String() == b.String()
+}
+
+type metricsRecorder struct {
+	t    *metricsTrace
+	next qlogwriter.Recorder
+}
+
+func (r metricsRecorder) RecordEvent(ev qlogwriter.Event) {
+	switch ev := ev.(type) {
+	case qlog.MetricsUpdated:
+		// Only changed metrics are set, the others are zero.
+		if ev.CongestionWindow != 0 {
+			r.t.lock.Lock()
+			r.t.congestionWindow = uint64(ev.CongestionWindow) //nolint:gosec // G115, never negative
+			r.t.lock.Unlock()
+		}
+	case qlog.MTUUpdated:
+		r.t.lock.Lock()
+		r.t.mtu = ev.Value
+		r.t.lock.Unlock()
+	case qlog.PacketSent:
+		r.t.onPacketSent(ev.Header.PacketType)
+		r.t.checkRemoteAddr()
+	case qlog.PacketReceived:
+		r.t.checkRemoteAddr()
+	}
+	if r.next != nil {
+		r.next.RecordEvent(ev)
+	}
+}
+
+func (r metricsRecorder) Close() error {
+	if r.next != nil {
+		return r.next.Close()
+	}
+
+	return nil
+}
+
+// bufferedWriteCloser flushes its buffer before closing the destination.
+type bufferedWriteCloser struct {
+	*bufio.Writer
+	c io.Closer
+}
+
+func newBufferedWriteCloser(w io.WriteCloser) *bufferedWriteCloser {
+	return &bufferedWriteCloser{Writer: bufio.NewWriter(w), c: w}
+}
+
+func (w *bufferedWriteCloser) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return w.c.Close()
+}
@@ -4,6 +4,7 @@
 package wrapper
 
 import (
+	"context"
 	"time"
 
 	quic "github.com/quic-go/quic-go"
@@ -43,6 +44,17 @@ func (s *WritableStream) Close() error {
 	return s.s.Close()
 }
 
+// Context is canceled once the stream is closed, reset or stopped, or the
+// connection is closed.
+func (s *WritableStream) Context() context.Context {
+	return s.s.Context()
+}
+
+// CancelWrite aborts sending on the stream with a RESET_STREAM frame.
+func (s *WritableStream) CancelWrite(code uint64) {
+	s.s.CancelWrite(quic.StreamErrorCode(code))
+}
+
 // SetWriteDeadline sets the deadline for future Write calls. A zero value for t means Write will not time out.
 func (s *WritableStream) SetWriteDeadline(t time.Time) error {
 	return s.s.SetWriteDeadline(t)
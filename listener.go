@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"net"
+
+	"github.com/pion/logging"
+	"github.com/pion/quic/internal/wrapper"
+)
+
+// Listener accepts incoming QUIC connections on a single UDP address.
+// Every accepted connection is returned as a started Transport.
+type Listener struct {
+	l             *wrapper.Listener
+	config        *Config
+	loggerFactory logging.LoggerFactory
+}
+
+// NewListener creates a Listener listening on the given address.
+func NewListener(url string, config *Config) (*Listener, error) {
+	if err := config.checkServer(); err != nil {
+		return nil, err
+	}
+
+	loggerFactory := config.LoggerFactory
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+
+	l, err := wrapper.Listen(url, config.clone())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Listener{
+		l:             l,
+		config:        config,
+		loggerFactory: loggerFactory,
+	}, nil
+}
+
+// Accept waits for and returns the next incoming Transport. It should be
+// called in a loop to serve multiple peers. With Config.Enable0RTT the
+// Transport is returned before its handshake completed, so that 0-RTT
+// data is served right away; see HandshakeComplete.
+func (l *Listener) Accept(ctx context.Context) (*Transport, error) {
+	s, err := l.l.Accept(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transport{}
+	t.TransportBase.log = l.loggerFactory.NewLogger("quic")
+
+	return t, t.TransportBase.startBase(s, l.config)
+}
+
+// Addr returns the local network address that the Listener is listening on.
+func (l *Listener) Addr() net.Addr {
+	return l.l.Addr()
+}
+
+// Close stops accepting new connections. Transports that have already been
+// accepted are not affected and have to be stopped individually.
+func (l *Listener) Close() error {
+	return l.l.Close()
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListener_AcceptMultiple(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cert, key, err := GenerateSelfSigned()
+	assert.NoError(t, err)
+
+	listener, err := NewListener("localhost:0", &Config{Certificate: cert, PrivateKey: key})
+	assert.NoError(t, err)
+
+	clientCount := 3
+	accepted := make(chan *Transport, clientCount)
+	go func() {
+		defer close(accepted)
+		for i := 0; i < clientCount; i++ {
+			transport, aErr := listener.Accept(context.Background())
+			if !assert.NoError(t, aErr) {
+				return
+			}
+			accepted <- transport
+		}
+	}()
+
+	var clients []*Transport
+	for i := 0; i < clientCount; i++ {
+		cert, key, err = GenerateSelfSigned()
+		assert.NoError(t, err)
+
+		client, dErr := NewTransport(listener.Addr().String(), &Config{Certificate: cert, PrivateKey: key})
+		assert.NoError(t, dErr)
+		clients = append(clients, client)
+	}
+
+	servers := 0
+	for server := range accepted {
+		servers++
+		assert.NoError(t, server.Stop(TransportStopInfo{}))
+	}
+	assert.Equal(t, clientCount, servers)
+
+	for _, client := range clients {
+		assert.NoError(t, client.Stop(TransportStopInfo{}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = listener.Accept(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.NoError(t, listener.Close())
+}
@@ -0,0 +1,281 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v3/packetio"
+)
+
+const (
+	// muxReceiveMTU is the size of the buffer packets are read into.
+	muxReceiveMTU = 8192
+	// muxMaxBufferSize is the number of bytes buffered for each MuxConn
+	// before packets are dropped.
+	muxMaxBufferSize = 1000 * 1000
+)
+
+// MatchFunc decides whether a packet is routed to a MuxConn. It is given
+// the whole packet, which it must not retain.
+type MatchFunc func(packet []byte) bool
+
+// MatchAll matches every packet.
+func MatchAll([]byte) bool {
+	return true
+}
+
+// MatchRange matches packets whose first byte is within [lower, upper].
+func MatchRange(lower, upper byte) MatchFunc {
+	return func(packet []byte) bool {
+		return len(packet) > 0 && packet[0] >= lower && packet[0] <= upper
+	}
+}
+
+// The following match functions classify packets by their first byte,
+// following RFC 7983 and its QUIC update, RFC 9443:
+//
+//	[0..3]     STUN
+//	[16..19]   ZRTP
+//	[20..63]   DTLS
+//	[64..79]   TURN channel
+//	[64..127]  QUIC short header
+//	[128..191] RTP/RTCP
+//	[192..255] QUIC long header
+//
+// QUIC short headers overlap with TURN channels, so both must not share a
+// socket.
+
+// MatchSTUN matches STUN packets.
+func MatchSTUN(packet []byte) bool {
+	return MatchRange(0, 3)(packet)
+}
+
+// MatchZRTP matches ZRTP packets.
+func MatchZRTP(packet []byte) bool {
+	return MatchRange(16, 19)(packet)
+}
+
+// MatchDTLS matches DTLS packets.
+func MatchDTLS(packet []byte) bool {
+	return MatchRange(20, 63)(packet)
+}
+
+// MatchTURNChannel matches TURN channel data.
+func MatchTURNChannel(packet []byte) bool {
+	return MatchRange(64, 79)(packet)
+}
+
+// MatchRTPOrRTCP matches RTP and RTCP packets.
+func MatchRTPOrRTCP(packet []byte) bool {
+	return MatchRange(128, 191)(packet)
+}
+
+// MatchQUIC matches QUIC packets with a long or a short header, which both
+// have the fixed bit set.
+func MatchQUIC(packet []byte) bool {
+	return MatchRange(64, 127)(packet) || MatchRange(192, 255)(packet)
+}
+
+// PacketMux demultiplexes the packets read from one net.Conn between
+// several MuxConns, for example to run QUIC next to STUN on the path
+// selected by ICE. Each packet goes to the first MuxConn whose MatchFunc
+// matches it, and is dropped if none does.
+type PacketMux struct {
+	conn net.Conn
+	log  logging.LeveledLogger
+
+	lock  sync.RWMutex
+	conns []*MuxConn
+
+	closed chan struct{}
+	done   chan struct{}
+}
+
+// NewPacketMux starts reading packets from conn. loggerFactory may be nil.
+func NewPacketMux(conn net.Conn, loggerFactory logging.LoggerFactory) *PacketMux {
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+
+	m := &PacketMux{
+		conn:   conn,
+		log:    loggerFactory.NewLogger("quic-mux"),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go m.readLoop()
+
+	return m
+}
+
+// NewConn returns a net.Conn that receives the packets matched by f and
+// writes to the underlying conn.
+func (m *PacketMux) NewConn(f MatchFunc) *MuxConn {
+	buffer := packetio.NewBuffer()
+	buffer.SetLimitSize(muxMaxBufferSize)
+	c := &MuxConn{
+		mux:    m,
+		match:  f,
+		buffer: buffer,
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	select {
+	case <-m.done:
+		_ = buffer.Close()
+	default:
+		m.conns = append(m.conns, c)
+	}
+
+	return c
+}
+
+func (m *PacketMux) removeConn(c *MuxConn) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i := range m.conns {
+		if m.conns[i] == c {
+			m.conns = append(m.conns[:i], m.conns[i+1:]...)
+
+			break
+		}
+	}
+}
+
+// Close closes the underlying conn and all MuxConns.
+func (m *PacketMux) Close() error {
+	m.lock.Lock()
+	select {
+	case <-m.closed:
+		m.lock.Unlock()
+
+		return nil
+	default:
+		close(m.closed)
+	}
+	m.lock.Unlock()
+
+	err := m.conn.Close()
+	<-m.done
+
+	return err
+}
+
+func (m *PacketMux) readLoop() {
+	defer func() {
+		m.lock.Lock()
+		conns := m.conns
+		m.conns = nil
+		close(m.done)
+		m.lock.Unlock()
+
+		for _, c := range conns {
+			_ = c.buffer.Close()
+		}
+	}()
+
+	buf := make([]byte, muxReceiveMTU)
+	for {
+		n, err := m.conn.Read(buf)
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+			return
+		case err != nil:
+			select {
+			case <-m.closed:
+			default:
+				m.log.Errorf("Failed to read from conn: %v", err)
+			}
+
+			return
+		}
+
+		m.dispatch(buf[:n])
+	}
+}
+
+func (m *PacketMux) dispatch(packet []byte) {
+	m.lock.RLock()
+	var target *MuxConn
+	for _, c := range m.conns {
+		if c.match(packet) {
+			target = c
+
+			break
+		}
+	}
+	m.lock.RUnlock()
+
+	if target == nil {
+		if len(packet) > 0 {
+			m.log.Debugf("Dropping packet with first byte %d: no matching conn", packet[0])
+		}
+
+		return
+	}
+	if _, err := target.buffer.Write(packet); err != nil {
+		m.log.Warnf("Dropping packet: %v", err)
+	}
+}
+
+// MuxConn is a net.Conn for the packets of a PacketMux that match its
+// MatchFunc.
+type MuxConn struct {
+	mux    *PacketMux
+	match  MatchFunc
+	buffer *packetio.Buffer
+}
+
+var _ net.Conn = (*MuxConn)(nil)
+
+// Read reads the next matched packet.
+func (c *MuxConn) Read(p []byte) (int, error) {
+	return c.buffer.Read(p)
+}
+
+// Write writes a packet to the underlying conn.
+func (c *MuxConn) Write(p []byte) (int, error) {
+	return c.mux.conn.Write(p)
+}
+
+// Close stops routing packets to the MuxConn. The underlying conn stays
+// open.
+func (c *MuxConn) Close() error {
+	c.mux.removeConn(c)
+
+	return c.buffer.Close()
+}
+
+// LocalAddr returns the local address of the underlying conn.
+func (c *MuxConn) LocalAddr() net.Addr {
+	return c.mux.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the underlying conn.
+func (c *MuxConn) RemoteAddr() net.Addr {
+	return c.mux.conn.RemoteAddr()
+}
+
+// SetDeadline sets the read deadline, see SetWriteDeadline.
+func (c *MuxConn) SetDeadline(t time.Time) error {
+	return c.buffer.SetReadDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future Read calls.
+func (c *MuxConn) SetReadDeadline(t time.Time) error {
+	return c.buffer.SetReadDeadline(t)
+}
+
+// SetWriteDeadline is a no-op, since the underlying conn is shared.
+func (c *MuxConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/dpipe"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketMux_SharedWithSTUN(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	connA, connB := dpipe.Pipe()
+	muxA, muxB := NewPacketMux(connA, nil), NewPacketMux(connB, nil)
+	quicA, quicB := muxA.NewConn(MatchQUIC), muxB.NewConn(MatchQUIC)
+	stunA, stunB := muxA.NewConn(MatchSTUN), muxB.NewConn(MatchSTUN)
+
+	cfgA, cfgB := newP2PConfigs(t)
+	a, b, errA, errB := startP2P(quicA, quicB, cfgA, cfgB)
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+
+	// Unmatched packets are dropped, STUN goes next to the QUIC session.
+	_, err := stunA.Write([]byte{0x80, 0x00})
+	require.NoError(t, err)
+	binding := []byte{0x00, 0x01, 0x00, 0x00}
+	_, err = stunA.Write(binding)
+	require.NoError(t, err)
+	buf := make([]byte, 16)
+	n, err := stunB.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, binding, buf[:n])
+
+	stream, err := a.CreateBidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("ping"), Finished: true}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	remote, err := b.AcceptBidirectionalStream(ctx)
+	require.NoError(t, err)
+	res, err := remote.ReadInto(buf)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "ping", string(buf[:res.Amount]))
+
+	assert.NoError(t, a.Stop(TransportStopInfo{}))
+	assert.NoError(t, b.Stop(TransportStopInfo{}))
+
+	// The mux outlives the QUIC session.
+	_, err = stunB.Write(binding)
+	require.NoError(t, err)
+	n, err = stunA.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, binding, buf[:n])
+
+	assert.NoError(t, muxA.Close())
+	assert.NoError(t, muxB.Close())
+	_, err = stunA.Read(buf)
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchFuncs(t *testing.T) {
+	matchers := map[string]MatchFunc{
+		"stun": MatchSTUN,
+		"zrtp": MatchZRTP,
+		"dtls": MatchDTLS,
+		"turn": MatchTURNChannel,
+		"rtp":  MatchRTPOrRTCP,
+		"quic": MatchQUIC,
+	}
+
+	for _, tc := range []struct {
+		first   byte
+		matches []string
+	}{
+		{0, []string{"stun"}},
+		{3, []string{"stun"}},
+		{4, nil},
+		{16, []string{"zrtp"}},
+		{20, []string{"dtls"}},
+		{63, []string{"dtls"}},
+		{64, []string{"turn", "quic"}},
+		{80, []string{"quic"}},
+		{127, []string{"quic"}},
+		{128, []string{"rtp"}},
+		{191, []string{"rtp"}},
+		{192, []string{"quic"}},
+		{255, []string{"quic"}},
+	} {
+		for name, match := range matchers {
+			assert.Equal(t, contains(tc.matches, name), match([]byte{tc.first, 0}), "%s(%d)", name, tc.first)
+		}
+	}
+
+	for name, match := range matchers {
+		assert.False(t, match(nil), name)
+	}
+	assert.True(t, MatchAll(nil))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
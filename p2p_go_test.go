@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/dpipe"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newP2PConfigs creates a client and a server Config with fresh certificates.
+func newP2PConfigs(t *testing.T) (*Config, *Config) {
+	t.Helper()
+
+	certA, keyA, err := GenerateSelfSigned()
+	require.NoError(t, err)
+	certB, keyB, err := GenerateSelfSigned()
+	require.NoError(t, err)
+
+	return &Config{Client: true, Certificate: certA, PrivateKey: keyA, HandshakeIdleTimeout: time.Second},
+		&Config{Certificate: certB, PrivateKey: keyB, HandshakeIdleTimeout: time.Second}
+}
+
+// startP2P starts a client and a server TransportBase over the given conns.
+func startP2P(connA, connB net.Conn, cfgA, cfgB *Config) (*TransportBase, *TransportBase, error, error) {
+	var a, b TransportBase
+	errB := make(chan error)
+	go func() {
+		errB <- b.StartBase(connB, cfgB)
+	}()
+	errA := a.StartBase(connA, cfgA)
+
+	return &a, &b, errA, <-errB
+}
+
+func TestTransportBase_StartBaseFingerprints(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cfgA, cfgB := newP2PConfigs(t)
+
+	paramsA, err := cfgA.LocalParameters()
+	require.NoError(t, err)
+	assert.Equal(t, RoleClient, paramsA.Role)
+	paramsB, err := cfgB.LocalParameters()
+	require.NoError(t, err)
+	assert.Equal(t, RoleServer, paramsB.Role)
+
+	cfgA.RemoteFingerprints = paramsB.Fingerprints
+	cfgB.RemoteFingerprints = paramsA.Fingerprints
+
+	connA, connB := dpipe.Pipe()
+	a, b, errA, errB := startP2P(connA, connB, cfgA, cfgB)
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+	assert.Equal(t, TransportStateConnected, a.State())
+	assert.Equal(t, TransportStateConnected, b.State())
+
+	assert.NoError(t, a.Stop(TransportStopInfo{}))
+	assert.NoError(t, b.Stop(TransportStopInfo{}))
+}
+
+func TestTransportBase_StartBaseFingerprintMismatch(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cfgA, cfgB := newP2PConfigs(t)
+
+	// The client expects a certificate the server does not have.
+	other, _, err := GenerateSelfSigned()
+	require.NoError(t, err)
+	fp, err := CertificateFingerprint(other, "sha-256")
+	require.NoError(t, err)
+	cfgA.RemoteFingerprints = []Fingerprint{fp}
+
+	connA, connB := dpipe.Pipe()
+	a, b, errA, errB := startP2P(connA, connB, cfgA, cfgB)
+	assert.ErrorContains(t, errA, ErrFingerprintMismatch.Error())
+	assert.Error(t, errB)
+	assert.Equal(t, TransportStateFailed, a.State())
+	assert.Equal(t, TransportStateFailed, b.State())
+}
+
+func TestCertificateFingerprint(t *testing.T) {
+	cert, _, err := GenerateSelfSigned()
+	require.NoError(t, err)
+
+	fp, err := CertificateFingerprint(cert, "SHA-256")
+	require.NoError(t, err)
+	assert.Equal(t, "sha-256", fp.Algorithm)
+	assert.Len(t, fp.Value, 32*3-1)
+
+	assert.NoError(t, verifyFingerprints([][]byte{cert.Raw}, []Fingerprint{
+		{Algorithm: "md5", Value: "00"},
+		{Algorithm: "sha-256", Value: fp.Value},
+	}))
+	assert.ErrorIs(t, verifyFingerprints(nil, []Fingerprint{fp}), ErrNoRemoteCertificate)
+
+	_, err = CertificateFingerprint(cert, "md5")
+	assert.ErrorIs(t, err, ErrUnsupportedFingerprintAlgorithm)
+}
+
+func TestTransportBase_StartBaseServerFingerprints(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cfgA, cfgB := newP2PConfigs(t)
+	other, _, err := GenerateSelfSigned()
+	require.NoError(t, err)
+	fp, err := CertificateFingerprint(other, "sha-256")
+	require.NoError(t, err)
+
+	// Without a client certificate the server cannot check fingerprints.
+	cfgB.RemoteFingerprints = []Fingerprint{fp}
+	cfgB.ClientAuth = ClientAuthNone
+	var server TransportBase
+	assert.ErrorIs(t, server.StartBase(nil, cfgB), ErrFingerprintsWithoutClientAuth)
+	_, err = NewListener("localhost:0", cfgB)
+	assert.ErrorIs(t, err, ErrFingerprintsWithoutClientAuth)
+
+	// The server checks an optional client certificate too.
+	cfgB.ClientAuth = ClientAuthRequest
+	connA, connB := dpipe.Pipe()
+	a, b, errA, errB := startP2P(connA, connB, cfgA, cfgB)
+	assert.ErrorContains(t, errB, ErrFingerprintMismatch.Error())
+	assert.Equal(t, TransportStateFailed, b.State())
+	// The client finishes its handshake first and only learns about the
+	// rejection afterwards.
+	if errA == nil {
+		assert.NoError(t, a.Stop(TransportStopInfo{}))
+	}
+}
+
+func TestTransport_FingerprintsOnResumption(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cert, key, err := GenerateSelfSigned()
+	require.NoError(t, err)
+	fp, err := CertificateFingerprint(cert, "sha-256")
+	require.NoError(t, err)
+	other, _, err := GenerateSelfSigned()
+	require.NoError(t, err)
+	otherFP, err := CertificateFingerprint(other, "sha-256")
+	require.NoError(t, err)
+
+	listener, err := NewListener("localhost:0", &Config{Certificate: cert, PrivateKey: key})
+	require.NoError(t, err)
+	go func() {
+		for {
+			server, aErr := listener.Accept(context.Background())
+			if aErr != nil {
+				return
+			}
+			server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+				_, cErr := io.Copy(stream.Writer(), stream)
+				assert.NoError(t, cErr)
+				assert.NoError(t, stream.CloseWrite())
+			})
+			server.OnStateChange(func(state TransportState) {
+				if state == TransportStateClosed {
+					assert.NoError(t, server.Stop(TransportStopInfo{}))
+				}
+			})
+		}
+	}()
+
+	cache := tls.NewLRUClientSessionCache(1)
+	connect := func(fingerprint Fingerprint) (*Transport, error) {
+		client, cErr := NewTransport(listener.Addr().String(), &Config{
+			Certificate:        cert,
+			PrivateKey:         key,
+			ClientSessionCache: cache,
+			RemoteFingerprints: []Fingerprint{fingerprint},
+		})
+		if cErr != nil {
+			return nil, cErr
+		}
+
+		// The echo makes sure the session ticket has arrived.
+		stream, cErr := client.CreateBidirectionalStream()
+		require.NoError(t, cErr)
+		require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("ping"), Finished: true}))
+		_, cErr = io.ReadAll(stream)
+		require.NoError(t, cErr)
+		require.NoError(t, client.Stop(TransportStopInfo{}))
+
+		return client, nil
+	}
+
+	client, err := connect(fp)
+	require.NoError(t, err)
+	assert.False(t, client.DidResume())
+	client, err = connect(fp)
+	require.NoError(t, err)
+	assert.True(t, client.DidResume())
+
+	// A resumed session is checked against the fingerprints as well.
+	_, err = connect(otherFP)
+	assert.ErrorContains(t, err, ErrFingerprintMismatch.Error())
+
+	assert.NoError(t, listener.Close())
+}
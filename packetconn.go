@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxFrameSize is the largest packet a length prefix can describe.
+	maxFrameSize = 1<<16 - 1
+	// frameHeaderSize is the size of the length prefix of RFC 4571.
+	frameHeaderSize = 2
+)
+
+// PacketConn adapts a connected net.Conn, such as the path selected by
+// ICE, to the net.PacketConn QUIC runs over. All packets are exchanged
+// with the remote address of the conn.
+//
+// By default every Read of the conn returns one packet. Over a
+// stream-oriented conn, such as TCP, packets are framed with a 2-byte
+// length prefix as in RFC 4571 instead, see NewStreamPacketConn. Packets that are not QUIC, or that do not
+// fit into the buffer given to ReadFrom, are dropped.
+//
+// SetReadBuffer and SetWriteBuffer size the socket of the conn, if it has
+// one, such as a connected *net.UDPConn.
+type PacketConn struct {
+	conn   net.Conn
+	raddr  net.Addr
+	stream bool
+
+	readLock sync.Mutex
+	readBuf  []byte
+	// readAmount is the part of a frame read so far. It survives read
+	// errors such as deadlines, so that the framing stays intact.
+	readAmount int
+
+	writeLock sync.Mutex
+	writeBuf  []byte
+	writeErr  error
+}
+
+// NewPacketConn creates a PacketConn over a conn that keeps packet
+// boundaries, such as UDP or an ICE path, whatever its address reports.
+func NewPacketConn(conn net.Conn) *PacketConn {
+	return newPacketConn(conn, false)
+}
+
+// NewStreamPacketConn creates a PacketConn that frames packets over the
+// stream-oriented conn.
+func NewStreamPacketConn(conn net.Conn) *PacketConn {
+	return newPacketConn(conn, true)
+}
+
+func newPacketConn(conn net.Conn, stream bool) *PacketConn {
+	raddr := conn.RemoteAddr()
+	if raddr == nil {
+		raddr = packetConnAddr{}
+	}
+
+	return &PacketConn{
+		conn:    conn,
+		raddr:   raddr,
+		stream:  stream,
+		readBuf: make([]byte, frameHeaderSize+maxFrameSize),
+	}
+}
+
+// ReadFrom reads the next QUIC packet into p. The returned address is
+// always the remote address of the conn. Once the conn is closed,
+// ReadFrom returns net.ErrClosed.
+func (c *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	for {
+		packet, err := c.readPacket()
+		if err != nil {
+			return 0, c.raddr, closedError(err)
+		}
+		if len(packet) > len(p) || !MatchQUIC(packet) {
+			continue
+		}
+
+		return copy(p, packet), c.raddr, nil
+	}
+}
+
+func (c *PacketConn) readPacket() ([]byte, error) {
+	if !c.stream {
+		n, err := c.conn.Read(c.readBuf)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.readBuf[:n], nil
+	}
+
+	for {
+		need := frameHeaderSize
+		if c.readAmount >= frameHeaderSize {
+			need += int(binary.BigEndian.Uint16(c.readBuf))
+		}
+		if c.readAmount == need && need > frameHeaderSize {
+			c.readAmount = 0
+
+			return c.readBuf[frameHeaderSize:need], nil
+		}
+		if c.readAmount == need {
+			// An empty frame carries no packet.
+			c.readAmount = 0
+
+			continue
+		}
+
+		n, err := c.conn.Read(c.readBuf[c.readAmount:need])
+		c.readAmount += n
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// closedError maps the errors returned by a closed conn to net.ErrClosed.
+func closedError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.ErrClosedPipe) {
+		return net.ErrClosed
+	}
+
+	return err
+}
+
+// WriteTo writes p to the conn. addr must be the remote address of the
+// conn, or nil.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if addr != nil && (addr.Network() != c.raddr.Network() || addr.String() != c.raddr.String()) {
+		return 0, ErrUnknownAddress
+	}
+	if !c.stream {
+		n, err := c.conn.Write(p)
+
+		return n, closedError(err)
+	}
+	if len(p) > maxFrameSize {
+		return 0, ErrPacketTooLarge
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	if c.writeErr != nil {
+		return 0, c.writeErr
+	}
+
+	c.writeBuf = binary.BigEndian.AppendUint16(c.writeBuf[:0], uint16(len(p))) //nolint:gosec // G115
+	c.writeBuf = append(c.writeBuf, p...)
+	n, err := c.conn.Write(c.writeBuf)
+	if err != nil {
+		err = closedError(err)
+		if n > 0 {
+			// The peer can no longer find the start of the next frame.
+			c.writeErr = err
+		}
+
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the conn.
+func (c *PacketConn) Close() error {
+	return c.conn.Close()
+}
+
+// LocalAddr returns the local address of the conn.
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the address all packets are exchanged with.
+func (c *PacketConn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+// SetDeadline sets the read and write deadlines of the conn.
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the conn. A ReadFrom that
+// times out in the middle of a frame continues that frame on the next call.
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the conn.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadBuffer sets the receive buffer size of the conn's socket. It is a
+// no-op if the conn has no socket buffers.
+func (c *PacketConn) SetReadBuffer(bytes int) error {
+	if conn, ok := c.conn.(interface{ SetReadBuffer(int) error }); ok {
+		return conn.SetReadBuffer(bytes)
+	}
+
+	return nil
+}
+
+// SetWriteBuffer sets the send buffer size of the conn's socket. It is a
+// no-op if the conn has no socket buffers.
+func (c *PacketConn) SetWriteBuffer(bytes int) error {
+	if conn, ok := c.conn.(interface{ SetWriteBuffer(int) error }); ok {
+		return conn.SetWriteBuffer(bytes)
+	}
+
+	return nil
+}
+
+// quicConn returns the net.PacketConn handed to quic-go. If the conn is a
+// socket, it also exposes SyscallConn, so that quic-go can check the buffer
+// sizes and set the DF bit. A method that is not always backed by a socket
+// would make quic-go fail, so it is not part of PacketConn itself.
+func (c *PacketConn) quicConn() net.PacketConn {
+	if conn, ok := c.conn.(syscall.Conn); ok {
+		return &syscallPacketConn{PacketConn: c, conn: conn}
+	}
+
+	return c
+}
+
+type syscallPacketConn struct {
+	*PacketConn
+	conn syscall.Conn
+}
+
+func (c *syscallPacketConn) SyscallConn() (syscall.RawConn, error) {
+	return c.conn.SyscallConn()
+}
+
+// packetConnAddr stands in for the remote address of conns without one.
+type packetConnAddr struct{}
+
+func (packetConnAddr) Network() string { return "packetconn" }
+func (packetConnAddr) String() string  { return "packetconn" }
@@ -0,0 +1,243 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"bytes"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/dpipe"
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type otherAddr struct{}
+
+func (otherAddr) Network() string { return "udp" }
+func (otherAddr) String() string  { return "192.0.2.1:4242" }
+
+func TestPacketConn_Datagram(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	connA, connB := dpipe.Pipe()
+	pcA, pcB := NewPacketConn(connA), NewPacketConn(connB)
+
+	go func() {
+		for _, packet := range [][]byte{
+			{0x00, 0x01},                   // STUN
+			{0x80, 0x60},                   // RTP
+			bytes.Repeat([]byte{0xc0}, 32), // QUIC, too large for the buffer
+			{0xc0, 0x00},                   // QUIC long header
+			{0x40, 0x01},                   // QUIC short header
+		} {
+			_, err := connA.Write(packet)
+			assert.NoError(t, err)
+		}
+	}()
+
+	buf := make([]byte, 16)
+	n, addr, err := pcB.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xc0, 0x00}, buf[:n])
+	assert.Equal(t, connB.RemoteAddr(), addr)
+
+	n, _, err = pcB.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x40, 0x01}, buf[:n])
+
+	// Writes only go to the remote address.
+	_, err = pcA.WriteTo([]byte{0x40}, otherAddr{})
+	assert.ErrorIs(t, err, ErrUnknownAddress)
+	go func() {
+		_, wErr := pcA.WriteTo([]byte{0x40, 0x02}, pcA.RemoteAddr())
+		assert.NoError(t, wErr)
+	}()
+	n, _, err = pcB.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x40, 0x02}, buf[:n])
+
+	// The pipe has no socket buffers to size.
+	assert.NoError(t, pcA.SetReadBuffer(1<<20))
+	assert.NoError(t, pcA.SetWriteBuffer(1<<20))
+	_, ok := pcA.quicConn().(syscall.Conn)
+	assert.False(t, ok)
+
+	assert.NoError(t, pcB.Close())
+	_, _, err = pcB.ReadFrom(buf)
+	assert.ErrorIs(t, err, net.ErrClosed)
+	assert.NoError(t, pcA.Close())
+}
+
+func TestPacketConn_Stream(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	connA, connB := net.Pipe()
+	pcA, pcB := NewStreamPacketConn(connA), NewStreamPacketConn(connB)
+
+	_, err := pcA.WriteTo(make([]byte, maxFrameSize+1), nil)
+	assert.ErrorIs(t, err, ErrPacketTooLarge)
+
+	go func() {
+		for _, packet := range [][]byte{
+			{0x00, 0x01},                   // STUN
+			{},                             // empty frame
+			bytes.Repeat([]byte{0xc0}, 32), // too large for the buffer
+			{0xc0, 0x00, 0x01},
+		} {
+			n, wErr := pcA.WriteTo(packet, nil)
+			assert.NoError(t, wErr)
+			assert.Equal(t, len(packet), n)
+		}
+	}()
+
+	buf := make([]byte, 16)
+	n, addr, err := pcB.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xc0, 0x00, 0x01}, buf[:n])
+	assert.Equal(t, connB.RemoteAddr(), addr)
+
+	// A deadline in the middle of a frame does not break the framing.
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		_, wErr := connA.Write([]byte{0x00, 0x02, 0x40})
+		assert.NoError(t, wErr)
+	}()
+	assert.NoError(t, pcB.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, _, err = pcB.ReadFrom(buf)
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+	<-written
+
+	assert.NoError(t, pcB.SetReadDeadline(time.Time{}))
+	go func() {
+		_, wErr := connA.Write([]byte{0x03})
+		assert.NoError(t, wErr)
+	}()
+	n, _, err = pcB.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x40, 0x03}, buf[:n])
+
+	assert.NoError(t, pcA.Close())
+	_, _, err = pcB.ReadFrom(buf)
+	assert.ErrorIs(t, err, net.ErrClosed)
+	assert.NoError(t, pcB.Close())
+}
+
+func TestTransportBase_StartBaseTCP(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	accepted := make(chan net.Conn)
+	go func() {
+		defer close(accepted)
+		conn, aErr := ln.Accept()
+		assert.NoError(t, aErr)
+		accepted <- conn
+	}()
+	connA, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	connB := <-accepted
+	require.NotNil(t, connB)
+	assert.NoError(t, ln.Close())
+
+	// Framing is never guessed from the address.
+	assert.False(t, NewPacketConn(connA).stream)
+	cfgA, cfgB := newP2PConfigs(t)
+	cfgA.FramePackets, cfgB.FramePackets = true, true
+	assert.True(t, cfgA.packetConn(connA).stream)
+	a, b, errA, errB := startP2P(connA, connB, cfgA, cfgB)
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+	assert.Equal(t, TransportStateConnected, a.State())
+	assert.Equal(t, TransportStateConnected, b.State())
+
+	// Packets larger than one TCP read have to survive the framing.
+	msg := bytes.Repeat([]byte("framed"), 10000)
+	s, err := a.CreateBidirectionalStream()
+	require.NoError(t, err)
+	go func() {
+		_, wErr := s.Writer().Write(msg)
+		assert.NoError(t, wErr)
+		assert.NoError(t, s.CloseWrite())
+	}()
+
+	streams := make(chan *BidirectionalStream, 1)
+	b.OnBidirectionalStream(func(s *BidirectionalStream) {
+		streams <- s
+	})
+	remote := <-streams
+	var received bytes.Buffer
+	_, err = remote.WriteTo(&received)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, received.Bytes())
+
+	assert.NoError(t, a.Stop(TransportStopInfo{}))
+	assert.NoError(t, b.Stop(TransportStopInfo{}))
+	assert.NoError(t, connA.Close())
+	assert.NoError(t, connB.Close())
+}
+
+func TestTransportBase_StartBaseConnectedUDP(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	sockA, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	addrA := sockA.LocalAddr().(*net.UDPAddr) //nolint:forcetypeassert
+	require.NoError(t, sockA.Close())
+	connB, err := net.DialUDP("udp", nil, addrA)
+	require.NoError(t, err)
+	connA, err := net.DialUDP("udp", addrA, connB.LocalAddr().(*net.UDPAddr)) //nolint:forcetypeassert
+	require.NoError(t, err)
+
+	// The socket buffers are sized, and quic-go can check them.
+	pc := NewPacketConn(connA)
+	assert.False(t, pc.stream)
+	assert.NoError(t, pc.SetReadBuffer(1<<16))
+	assert.NoError(t, pc.SetWriteBuffer(1<<16))
+	_, ok := pc.quicConn().(syscall.Conn)
+	assert.True(t, ok)
+
+	cfgA, cfgB := newP2PConfigs(t)
+	a, b, errA, errB := startP2P(connA, connB, cfgA, cfgB)
+	require.NoError(t, errA)
+	require.NoError(t, errB)
+	assert.Equal(t, TransportStateConnected, a.State())
+	assert.Equal(t, TransportStateConnected, b.State())
+
+	assert.NoError(t, a.Stop(TransportStopInfo{}))
+	assert.NoError(t, b.Stop(TransportStopInfo{}))
+	assert.NoError(t, connA.Close())
+	assert.NoError(t, connB.Close())
+}
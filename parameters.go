@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+// Role indicates the role of a Transport in the QUIC handshake, following
+// RTCQuicRole.
+type Role int
+
+const (
+	// RoleAuto indicates the role has not been decided yet.
+	RoleAuto Role = iota
+
+	// RoleClient indicates the Transport initiates the QUIC handshake.
+	RoleClient
+
+	// RoleServer indicates the Transport waits for the peer to initiate the
+	// QUIC handshake.
+	RoleServer
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleAuto:
+		return "auto"
+	case RoleClient:
+		return "client"
+	case RoleServer:
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
+// Parameters holds the information that is signaled to the remote peer,
+// following RTCQuicParameters.
+type Parameters struct {
+	Role         Role
+	Fingerprints []Fingerprint
+}
+
+// LocalParameters returns the Parameters describing a Transport started
+// with this Config. The peer passes the fingerprints to StartBase through
+// Config.RemoteFingerprints.
+func (c *Config) LocalParameters() (Parameters, error) {
+	if c.Certificate == nil {
+		return Parameters{}, ErrNoCertificate
+	}
+
+	fp, err := CertificateFingerprint(c.Certificate, "sha-256")
+	if err != nil {
+		return Parameters{}, err
+	}
+
+	role := RoleServer
+	if c.Client {
+		role = RoleClient
+	}
+
+	return Parameters{
+		Role:         role,
+		Fingerprints: []Fingerprint{fp},
+	}, nil
+}
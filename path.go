@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"net"
+
+	quic "github.com/quic-go/quic-go"
+)
+
+// Path is a network path a client Transport can migrate to, for example
+// when the device switches from Wi-Fi to a mobile network.
+type Path struct {
+	p *quic.Path
+}
+
+// Probe validates the path with the peer. It blocks until the peer
+// responded or ctx is done.
+func (p *Path) Probe(ctx context.Context) error {
+	return wrapError(p.p.Probe(ctx))
+}
+
+// Switch moves the connection to the path. The path should be probed
+// first.
+func (p *Path) Switch() error {
+	return wrapError(p.p.Switch())
+}
+
+// Close abandons the path. The active path cannot be closed.
+func (p *Path) Close() error {
+	return wrapError(p.p.Close())
+}
+
+// AddPath adds a path over conn to a client TransportBase. The path stops
+// using conn once the TransportBase is stopped, but conn is not closed.
+func (b *TransportBase) AddPath(conn net.PacketConn) (*Path, error) {
+	p, err := b.session.AddPath(conn)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return &Path{p: p}, nil
+}
+
+// Migrate adds a path over conn, probes it and switches to it. The path is
+// closed again if that fails.
+func (b *TransportBase) Migrate(ctx context.Context, conn net.PacketConn) (*Path, error) {
+	path, err := b.AddPath(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err = path.Probe(ctx); err == nil {
+		err = path.Switch()
+	}
+	if err != nil {
+		if closeErr := path.Close(); closeErr != nil {
+			b.log.Warnf("Failed to close path: %v", closeErr)
+		}
+
+		return nil, err
+	}
+
+	return path, nil
+}
+
+// OnRemoteAddressChange sets an event handler which is fired when the
+// peer's address changes, after a NAT rebinding or a migration of the peer.
+func (b *TransportBase) OnRemoteAddressChange(f func(net.Addr)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.onRemoteAddressChangeHdlr = f
+}
+
+// onRemoteAddressChange runs on its own goroutine, see
+// wrapper.Conn.OnRemoteAddressChange, so it may wait for the lock held by
+// Stop.
+func (b *TransportBase) onRemoteAddressChange(addr net.Addr) {
+	b.lock.RLock()
+	f := b.onRemoteAddressChangeHdlr
+	b.lock.RUnlock()
+
+	if f != nil {
+		f(addr)
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_Migrate(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	remoteAddrs := make(chan net.Addr, 1)
+	server.OnRemoteAddressChange(func(addr net.Addr) {
+		remoteAddrs <- addr
+	})
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		_, err := io.Copy(stream.Writer(), stream)
+		assert.NoError(t, err)
+		assert.NoError(t, stream.CloseWrite())
+	})
+
+	// Switch to a second socket, as if the client moved to another network.
+	conn, err := net.ListenUDP("udp", nil)
+	require.NoError(t, err)
+
+	// The server cannot migrate.
+	_, err = server.AddPath(conn)
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	path, err := client.Migrate(ctx, conn)
+	require.NoError(t, err)
+	require.NotNil(t, path)
+
+	stream, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("ping"), Finished: true}))
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(data))
+
+	select {
+	case addr := <-remoteAddrs:
+		udpAddr, ok := addr.(*net.UDPAddr)
+		require.True(t, ok)
+		assert.Equal(t, conn.LocalAddr().(*net.UDPAddr).Port, udpAddr.Port) //nolint:forcetypeassert
+	case <-ctx.Done():
+		assert.Fail(t, "remote address change not reported")
+	}
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+	assert.NoError(t, conn.Close())
+}
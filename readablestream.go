@@ -4,6 +4,8 @@
 package quic
 
 import (
+	"context"
+	"io"
 	"time"
 
 	"github.com/pion/quic/internal/wrapper"
@@ -15,17 +17,55 @@ type StreamID int64
 
 // ReadableStream represents a unidirectional quic ReceiveStream.
 type ReadableStream struct {
-	s *wrapper.ReadableStream
+	s  *wrapper.ReadableStream
+	rb *readBuffer
+}
+
+func newReadableStream(s *wrapper.ReadableStream, lifetime *streamLifetime) *ReadableStream {
+	return &ReadableStream{
+		s:  s,
+		rb: newReadBuffer(s, lifetime),
+	}
 }
 
 // ReadInto reads from the ReadableStream into the buffer.
 func (s *ReadableStream) ReadInto(data []byte) (StreamReadResult, error) {
-	n, fin, err := s.s.ReadQuic(data)
+	n, fin, err := s.rb.read(data)
 
 	return StreamReadResult{
 		Amount:   n,
 		Finished: fin,
-	}, err
+	}, wrapStreamError(err, false)
+}
+
+// Read implements io.Reader. It returns io.EOF once the peer has finished
+// the stream.
+func (s *ReadableStream) Read(p []byte) (int, error) {
+	n, _, err := s.rb.read(p)
+
+	return n, wrapStreamError(err, false)
+}
+
+// WriteTo implements io.WriterTo, copying the stream into w until the peer
+// finishes it.
+func (s *ReadableStream) WriteTo(w io.Writer) (int64, error) {
+	return copyChunks(s.Read, w.Write)
+}
+
+// ReadBufferedAmount returns the number of bytes that WaitForReadable
+// pulled from the QUIC stack and that have not been read yet. Unlike the
+// W3C readBufferedAmount it does not count data the stack received while
+// nobody waited, so it can be 0 although a read would not block; use
+// WaitForReadable instead of polling it.
+func (s *ReadableStream) ReadBufferedAmount() int {
+	return s.rb.bufferedAmount()
+}
+
+// WaitForReadable blocks until amount bytes can be read without blocking,
+// the peer finished the stream, or ctx is done. It must not be called
+// concurrently with reads.
+func (s *ReadableStream) WaitForReadable(ctx context.Context, amount int) error {
+	return wrapStreamError(s.rb.waitReadable(ctx, amount), false)
 }
 
 // StreamID returns the ID of the ReadableStream.
@@ -33,8 +73,22 @@ func (s *ReadableStream) StreamID() StreamID {
 	return StreamID(s.s.StreamID())
 }
 
+// StopSending aborts reading from the stream, following abortReading. The
+// peer's writes fail with a StreamError carrying code.
+func (s *ReadableStream) StopSending(code ErrorCode) error {
+	if err := code.validate(); err != nil {
+		return err
+	}
+	s.s.CancelRead(uint64(code))
+	s.rb.lifetime.closeRead()
+
+	return nil
+}
+
 // SetReadDeadline sets the deadline for future Read calls. A zero value for t means Read will not time out.
 func (s *ReadableStream) SetReadDeadline(t time.Time) error {
+	s.rb.setReadDeadline(t)
+
 	return s.s.SetReadDeadline(t)
 }
 
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// readBufferChunkSize is the minimum number of bytes requested from the
+// stream while waiting for it to become readable.
+const readBufferChunkSize = 4096
+
+type quicReader interface {
+	ReadQuic(p []byte) (int, bool, error)
+	SetReadDeadline(t time.Time) error
+}
+
+// readBuffer holds data that WaitForReadable received ahead of the
+// application, and hands it out before reading from the stream again.
+type readBuffer struct {
+	r        quicReader
+	lifetime *streamLifetime
+
+	// readLock serializes reads from r.
+	readLock sync.Mutex
+
+	lock     sync.Mutex
+	buf      []byte
+	err      error // a final error, including io.EOF
+	deadline time.Time
+}
+
+func newReadBuffer(r quicReader, lifetime *streamLifetime) *readBuffer {
+	return &readBuffer{r: r, lifetime: lifetime}
+}
+
+func (b *readBuffer) read(p []byte) (int, bool, error) {
+	b.readLock.Lock()
+	defer b.readLock.Unlock()
+
+	b.lock.Lock()
+	if len(b.buf) > 0 {
+		n := copy(p, b.buf)
+		b.buf = b.buf[n:]
+		defer b.lock.Unlock()
+		if len(b.buf) == 0 && b.err != nil {
+			return n, true, b.err
+		}
+
+		return n, false, nil
+	}
+	if b.err != nil {
+		defer b.lock.Unlock()
+
+		return 0, true, b.err
+	}
+	b.lock.Unlock()
+
+	n, fin, err := b.r.ReadQuic(p)
+	if fin {
+		b.lifetime.closeRead()
+	}
+
+	return n, fin, err
+}
+
+// waitReadable blocks until amount bytes or the end of the stream are
+// buffered, or ctx is done.
+func (b *readBuffer) waitReadable(ctx context.Context, amount int) error {
+	b.readLock.Lock()
+	defer b.readLock.Unlock()
+
+	// A cancelled ctx interrupts the pending read through the read deadline.
+	cancelled := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(cancelled)
+		_ = b.r.SetReadDeadline(time.Now())
+	})
+	defer func() {
+		if !stop() {
+			<-cancelled
+			b.lock.Lock()
+			_ = b.r.SetReadDeadline(b.deadline)
+			b.lock.Unlock()
+		}
+	}()
+
+	for {
+		b.lock.Lock()
+		buffered, err := len(b.buf), b.err
+		b.lock.Unlock()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		case buffered >= amount:
+			return nil
+		}
+
+		chunk := make([]byte, max(amount-buffered, readBufferChunkSize))
+		n, fin, err := b.r.ReadQuic(chunk)
+		if fin {
+			b.lifetime.closeRead()
+		}
+
+		b.lock.Lock()
+		b.buf = append(b.buf, chunk[:n]...)
+		var netErr net.Error
+		timeout := errors.As(err, &netErr) && netErr.Timeout()
+		if err != nil && !timeout {
+			b.err = err
+		}
+		b.lock.Unlock()
+
+		if timeout {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			return err
+		}
+	}
+}
+
+func (b *readBuffer) bufferedAmount() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return len(b.buf)
+}
+
+// setReadDeadline records the deadline so it can be restored after
+// waitReadable was cancelled.
+func (b *readBuffer) setReadDeadline(t time.Time) {
+	b.lock.Lock()
+	b.deadline = t
+	b.lock.Unlock()
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"sync"
+	"time"
+)
+
+// TransportStats holds statistics of a Transport, modeled on
+// RTCQuicTransportStats.
+type TransportStats struct {
+	// Timestamp is the time the statistics were collected.
+	Timestamp time.Time
+
+	// BytesSent and BytesReceived count the bytes of all QUIC packets,
+	// including retransmissions and duplicates.
+	BytesSent     uint64
+	BytesReceived uint64
+	// PacketsSent and PacketsReceived count QUIC packets, including those
+	// lost or not processable.
+	PacketsSent     uint64
+	PacketsReceived uint64
+	// PacketsLost and BytesLost count packets declared lost. They can
+	// decrease when a packet declared lost arrives after all.
+	PacketsLost uint64
+	BytesLost   uint64
+
+	SmoothedRTT  time.Duration
+	MinRTT       time.Duration
+	LatestRTT    time.Duration
+	RTTVariation time.Duration
+
+	// CongestionWindow is the congestion window in bytes.
+	CongestionWindow uint64
+
+	// OutgoingStreamsCreated and IncomingStreamsCreated count the streams
+	// opened by this endpoint and by the peer.
+	OutgoingStreamsCreated uint64
+	IncomingStreamsCreated uint64
+	// OpenOutgoingStreams and OpenIncomingStreams count the streams of
+	// which a side has not yet been finished, reset or failed, as seen by
+	// this endpoint. Detached streams stay open.
+	OpenOutgoingStreams uint64
+	OpenIncomingStreams uint64
+
+	// DatagramsSent counts the datagrams handed to the QUIC stack and
+	// DatagramsDropped those dropped by the send queue or the stack.
+	DatagramsSent    uint64
+	DatagramsDropped uint64
+}
+
+// GetStats returns the statistics of the Transport.
+func (b *TransportBase) GetStats() TransportStats {
+	stats := TransportStats{Timestamp: time.Now()}
+
+	if b.session != nil {
+		connStats := b.session.ConnectionStats()
+		stats.BytesSent = connStats.BytesSent
+		stats.BytesReceived = connStats.BytesReceived
+		stats.PacketsSent = connStats.PacketsSent
+		stats.PacketsReceived = connStats.PacketsReceived
+		stats.PacketsLost = connStats.PacketsLost
+		stats.BytesLost = connStats.BytesLost
+		stats.SmoothedRTT = connStats.SmoothedRTT
+		stats.MinRTT = connStats.MinRTT
+		stats.LatestRTT = connStats.LatestRTT
+		stats.RTTVariation = connStats.MeanDeviation
+		stats.CongestionWindow = b.session.CongestionWindow()
+	}
+
+	b.streams.lock.Lock()
+	stats.OutgoingStreamsCreated = b.streams.outgoingCreated
+	stats.IncomingStreamsCreated = b.streams.incomingCreated
+	stats.OpenOutgoingStreams = b.streams.outgoingOpen
+	stats.OpenIncomingStreams = b.streams.incomingOpen
+	b.streams.lock.Unlock()
+
+	if b.datagrams != nil {
+		b.datagrams.lock.Lock()
+		stats.DatagramsSent = b.datagrams.sent
+		stats.DatagramsDropped = b.datagrams.dropped
+		b.datagrams.lock.Unlock()
+	}
+
+	return stats
+}
+
+// streamCounters counts the streams of a Transport.
+type streamCounters struct {
+	lock            sync.Mutex
+	outgoingCreated uint64
+	incomingCreated uint64
+	outgoingOpen    uint64
+	incomingOpen    uint64
+}
+
+// track counts a new stream and returns its lifetime.
+func (c *streamCounters) track(incoming, readable, writable bool) *streamLifetime {
+	c.lock.Lock()
+	if incoming {
+		c.incomingCreated++
+		c.incomingOpen++
+	} else {
+		c.outgoingCreated++
+		c.outgoingOpen++
+	}
+	c.lock.Unlock()
+
+	return &streamLifetime{
+		readOpen:  readable,
+		writeOpen: writable,
+		onClose: func() {
+			c.lock.Lock()
+			if incoming {
+				c.incomingOpen--
+			} else {
+				c.outgoingOpen--
+			}
+			c.lock.Unlock()
+		},
+	}
+}
+
+// streamLifetime calls onClose once all sides of a stream are done.
+type streamLifetime struct {
+	lock      sync.Mutex
+	readOpen  bool
+	writeOpen bool
+	onClose   func()
+}
+
+func (l *streamLifetime) closeRead() {
+	l.close(&l.readOpen)
+}
+
+func (l *streamLifetime) closeWrite() {
+	l.close(&l.writeOpen)
+}
+
+func (l *streamLifetime) close(side *bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !*side {
+		return
+	}
+	*side = false
+	if !l.readOpen && !l.writeOpen {
+		l.onClose()
+	}
+}
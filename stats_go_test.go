@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_GetStats(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{EnableDatagrams: true}, &Config{EnableDatagrams: true})
+
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		_, err := io.Copy(stream.Writer(), stream)
+		assert.NoError(t, err)
+		assert.NoError(t, stream.CloseWrite())
+	})
+
+	// A stream finished in both directions is no longer open.
+	bidi, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, bidi.Write(StreamWriteParameters{Data: []byte("ping"), Finished: true}))
+	_, err = io.ReadAll(bidi)
+	require.NoError(t, err)
+
+	_, err = client.CreateUnidirectionalStream()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.SendDatagram([]byte("ping")))
+	_, err = server.ReceiveDatagram(ctx)
+	require.NoError(t, err)
+
+	var stats TransportStats
+	// The datagram is counted once the queue handed it over.
+	require.Eventually(t, func() bool {
+		stats = client.GetStats()
+
+		return stats.DatagramsSent == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, stats.Timestamp.IsZero())
+	assert.Greater(t, stats.BytesSent, uint64(0))
+	assert.Greater(t, stats.BytesReceived, uint64(0))
+	assert.Greater(t, stats.PacketsSent, uint64(0))
+	assert.Greater(t, stats.PacketsReceived, uint64(0))
+	assert.Greater(t, stats.SmoothedRTT, time.Duration(0))
+	assert.Greater(t, stats.CongestionWindow, uint64(0))
+	assert.Equal(t, uint64(2), stats.OutgoingStreamsCreated)
+	assert.Equal(t, uint64(1), stats.OpenOutgoingStreams)
+	assert.Equal(t, uint64(0), stats.IncomingStreamsCreated)
+	assert.Equal(t, uint64(0), stats.DatagramsDropped)
+
+	assert.Eventually(t, func() bool {
+		stats = server.GetStats()
+
+		return stats.IncomingStreamsCreated == 1 && stats.OpenIncomingStreams == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
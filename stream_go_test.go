@@ -0,0 +1,566 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_ResetAndStopSending(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	bidiStreams := make(chan *BidirectionalStream, 1)
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		bidiStreams <- stream
+	})
+	uniStreams := make(chan *ReadableStream, 1)
+	server.OnUnidirectionalStream(func(stream *ReadableStream) {
+		uniStreams <- stream
+	})
+
+	// Reset by the sender is seen by the receiver.
+	bidi, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, bidi.Write(StreamWriteParameters{Data: []byte("hello")}))
+
+	remoteBidi := <-bidiStreams
+	buf := make([]byte, 16)
+	res, err := remoteBidi.ReadInto(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf[:res.Amount]))
+
+	assert.ErrorIs(t, bidi.Reset(MaxErrorCode+1), ErrInvalidErrorCode)
+	require.NoError(t, bidi.Reset(5))
+
+	_, err = remoteBidi.ReadInto(buf)
+	var streamErr *StreamError
+	require.True(t, errors.As(err, &streamErr))
+	assert.Equal(t, ErrorCode(5), streamErr.ErrorCode)
+	assert.True(t, streamErr.Remote)
+	assert.False(t, streamErr.StopSending)
+
+	// StopSending by the receiver is seen by the sender.
+	uni, err := client.CreateUnidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, uni.Write(StreamWriteParameters{Data: []byte("hello")}))
+
+	remoteUni := <-uniStreams
+	require.NoError(t, remoteUni.StopSending(6))
+
+	// Writes fail once the STOP_SENDING frame arrived.
+	for err = nil; err == nil; {
+		time.Sleep(10 * time.Millisecond)
+		err = uni.Write(StreamWriteParameters{Data: []byte("hello")})
+	}
+	require.True(t, errors.As(err, &streamErr))
+	assert.Equal(t, uni.StreamID(), streamErr.StreamID)
+	assert.Equal(t, ErrorCode(6), streamErr.ErrorCode)
+	assert.True(t, streamErr.Remote)
+	assert.True(t, streamErr.StopSending)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestTransportBase_PendingStreams(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	// Streams opened before any handler is set are accepted.
+	bidi, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, bidi.Write(StreamWriteParameters{Data: []byte("bidi")}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	remoteBidi, err := server.AcceptBidirectionalStream(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, bidi.StreamID(), remoteBidi.StreamID())
+
+	// Pending streams are flushed to a handler set later.
+	for i := 0; i < 2; i++ {
+		uni, cErr := client.CreateUnidirectionalStream()
+		require.NoError(t, cErr)
+		require.NoError(t, uni.Write(StreamWriteParameters{Data: []byte("uni"), Finished: true}))
+	}
+	assert.Eventually(t, func() bool {
+		server.lock.Lock()
+		defer server.lock.Unlock()
+
+		return len(server.pendingUniStreams) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	uniStreams := make(chan *ReadableStream, 2)
+	server.OnUnidirectionalStream(func(stream *ReadableStream) {
+		uniStreams <- stream
+	})
+	<-uniStreams
+	<-uniStreams
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	_, err = server.AcceptUnidirectionalStream(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+
+	_, err = server.AcceptBidirectionalStream(ctx)
+	var transportErr *TransportError
+	assert.True(t, errors.As(err, &transportErr))
+
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestTransportBase_CreateStreamContext(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{MaxIncomingStreams: 1})
+
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		read := &sync.WaitGroup{}
+		read.Add(1)
+		readBidiLoop(t, stream, io.Discard, read)
+		assert.NoError(t, stream.Write(StreamWriteParameters{Finished: true}))
+	})
+
+	stream, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+
+	// The peer allows a single stream, so the next one has to wait.
+	_, err = client.CreateBidirectionalStream()
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.CreateBidirectionalStreamContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Closing both sides of the first stream returns the credit.
+	require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("done"), Finished: true}))
+	done := &sync.WaitGroup{}
+	done.Add(1)
+	readBidiLoop(t, stream, io.Discard, done)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	next, err := client.CreateBidirectionalStreamContext(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, stream.StreamID(), next.StreamID())
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStream_IOAdapters(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	// The server echoes bidirectional streams and collects unidirectional ones.
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		_, err := io.Copy(stream.Writer(), stream)
+		assert.NoError(t, err)
+		assert.NoError(t, stream.CloseWrite())
+	})
+	uniData := make(chan []byte, 1)
+	server.OnUnidirectionalStream(func(stream *ReadableStream) {
+		data, err := io.ReadAll(stream)
+		assert.NoError(t, err)
+		uniData <- data
+	})
+
+	payload := bytes.Repeat([]byte("pion-quic"), 100000)
+
+	bidi, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	echoed := &bytes.Buffer{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := io.Copy(echoed, bidi)
+		assert.NoError(t, err)
+	}()
+	n, err := io.Copy(bidi.Writer(), bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), n)
+	require.NoError(t, bidi.Close())
+	<-done
+	assert.Equal(t, payload, echoed.Bytes())
+
+	uni, err := client.CreateUnidirectionalStream()
+	require.NoError(t, err)
+	w := uni.Writer()
+	n, err = w.ReadFrom(bytes.NewReader(payload))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), n)
+	assert.Zero(t, uni.WriteBufferedAmount())
+	require.NoError(t, w.Close())
+	assert.Equal(t, payload, <-uniData)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStreamConn(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	serverConns := make(chan net.Conn, 1)
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		serverConns <- stream.NetConn()
+	})
+
+	bidi, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	var clientConn net.Conn = bidi.NetConn()
+
+	_, err = clientConn.Write([]byte("ping"))
+	require.NoError(t, err)
+	serverConn := <-serverConns
+
+	// The listener is bound to the unspecified address, so compare ports.
+	port := func(addr net.Addr) int {
+		udpAddr, ok := addr.(*net.UDPAddr)
+		require.True(t, ok)
+
+		return udpAddr.Port
+	}
+	assert.Equal(t, port(clientConn.LocalAddr()), port(serverConn.RemoteAddr()))
+	assert.Equal(t, port(clientConn.RemoteAddr()), port(serverConn.LocalAddr()))
+
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(serverConn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	// A read deadline does not affect writes.
+	require.NoError(t, clientConn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = clientConn.Read(buf)
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr))
+	assert.True(t, netErr.Timeout())
+	_, err = clientConn.Write([]byte("pong"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(serverConn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(buf))
+
+	// Close half-closes the sending side, the peer sees EOF.
+	require.NoError(t, clientConn.Close())
+	_, err = serverConn.Read(buf)
+	assert.ErrorIs(t, err, io.EOF)
+	// The client also stopped receiving, so the server can no longer finish
+	// its sending side.
+	assert.Error(t, serverConn.Close())
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStream_QueueWrite(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxBuffered = 1 << 14
+	client, server, listener := newTransportPair(t, &Config{MaxWriteBufferedAmount: maxBuffered}, &Config{})
+
+	received := make(chan []byte, 1)
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		data, err := io.ReadAll(stream)
+		assert.NoError(t, err)
+		received <- data
+	})
+
+	stream, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	assert.Equal(t, maxBuffered, stream.MaxWriteBufferedAmount())
+	assert.ErrorIs(t, stream.QueueWrite(StreamWriteParameters{Data: make([]byte, maxBuffered+1)}), ErrWriteBufferFull)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	expected := &bytes.Buffer{}
+	chunk := make([]byte, 1000)
+	for i := 0; i < 500; i++ {
+		for j := range chunk {
+			chunk[j] = byte(i)
+		}
+		require.NoError(t, stream.WaitForWriteBufferedAmountBelow(ctx, maxBuffered-len(chunk)))
+		require.NoError(t, stream.QueueWrite(StreamWriteParameters{Data: chunk}))
+		assert.LessOrEqual(t, stream.WriteBufferedAmount(), maxBuffered)
+		expected.Write(chunk)
+	}
+
+	// A synchronous write is sent after the queued data.
+	require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("end"), Finished: true}))
+	expected.WriteString("end")
+	assert.Equal(t, 0, stream.WriteBufferedAmount())
+	assert.Equal(t, expected.Bytes(), <-received)
+
+	require.NoError(t, stream.WaitForWriteBufferedAmountBelow(ctx, 0))
+
+	uni, err := client.CreateUnidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, uni.QueueWrite(StreamWriteParameters{Data: []byte("last"), Finished: true}))
+	assert.ErrorIs(t, uni.QueueWrite(StreamWriteParameters{Data: []byte("more")}), ErrStreamFinished)
+	require.NoError(t, uni.WaitForWriteBufferedAmountBelow(ctx, 0))
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStream_WaitForReadable(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	streams := make(chan *ReadableStream, 1)
+	server.OnUnidirectionalStream(func(stream *ReadableStream) {
+		streams <- stream
+	})
+
+	uni, err := client.CreateUnidirectionalStream()
+	require.NoError(t, err)
+	require.NoError(t, uni.Write(StreamWriteParameters{Data: []byte("head")}))
+	stream := <-streams
+
+	// Not enough data, the wait is cancelled by the context.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, stream.WaitForReadable(ctx, 8), context.DeadlineExceeded)
+	assert.Equal(t, 4, stream.ReadBufferedAmount())
+
+	require.NoError(t, uni.Write(StreamWriteParameters{Data: []byte("er-body")}))
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, stream.WaitForReadable(ctx, 8))
+	assert.GreaterOrEqual(t, stream.ReadBufferedAmount(), 8)
+
+	buf := make([]byte, 8)
+	res, err := stream.ReadInto(buf)
+	require.NoError(t, err)
+	assert.False(t, res.Finished)
+	assert.Equal(t, "header-b", string(buf[:res.Amount]))
+
+	// The end of the stream also makes it readable.
+	require.NoError(t, uni.Write(StreamWriteParameters{Finished: true}))
+	require.NoError(t, stream.WaitForReadable(ctx, 100))
+	rest, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	assert.Equal(t, "ody", string(rest))
+	assert.Equal(t, 0, stream.ReadBufferedAmount())
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStream_CloseWriteDuringBlockedWrite(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const window = 1 << 10
+	client, server, listener := newTransportPair(t, &Config{}, &Config{
+		InitialStreamReceiveWindow: window,
+		MaxStreamReceiveWindow:     window,
+	})
+
+	accepted := make(chan *BidirectionalStream, 1)
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		accepted <- stream
+	})
+
+	stream, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+
+	// The peer does not read, so the write blocks on flow control.
+	writeErr := make(chan error)
+	go func() {
+		_, wErr := stream.Writer().Write(make([]byte, 16*window))
+		writeErr <- wErr
+	}()
+	remote := <-accepted
+	select {
+	case err = <-writeErr:
+		t.Fatalf("write did not block: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Finishing the stream does not wait for the blocked write.
+	require.NoError(t, stream.CloseWrite())
+	assert.NoError(t, stream.Writer().Close())
+	_, err = stream.Writer().Write([]byte("more"))
+	assert.ErrorIs(t, err, ErrStreamFinished)
+
+	require.NoError(t, stream.SetWriteDeadline(time.Now()))
+	assert.ErrorIs(t, <-writeErr, os.ErrDeadlineExceeded)
+
+	// The FIN follows the part of the blocked write that was sent.
+	data, err := io.ReadAll(remote)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, data)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStream_WriteDeadlineDuringFlush(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const window = 1 << 10
+	client, server, listener := newTransportPair(t, &Config{}, &Config{
+		InitialStreamReceiveWindow: window,
+		MaxStreamReceiveWindow:     window,
+	})
+
+	accepted := make(chan *BidirectionalStream, 1)
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		accepted <- stream
+	})
+
+	stream, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+
+	// The peer does not read, so the flush blocks on flow control.
+	msg := bytes.Repeat([]byte("queued"), 4*window)
+	require.NoError(t, stream.QueueWrite(StreamWriteParameters{Data: msg}))
+	remote := <-accepted
+
+	// A deadline meant for a synchronous write interrupts the flush, but
+	// does not drop the queued data.
+	require.NoError(t, stream.SetWriteDeadline(time.Now()))
+	err = stream.Write(StreamWriteParameters{Data: []byte("sync")})
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	time.Sleep(50 * time.Millisecond)
+	assert.NotZero(t, stream.WriteBufferedAmount())
+	require.NoError(t, stream.CloseWrite())
+
+	require.NoError(t, stream.SetWriteDeadline(time.Time{}))
+	data, err := io.ReadAll(remote)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, data)
+	assert.Zero(t, stream.WriteBufferedAmount())
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestStreamConn_CloseDuringBlockedWrite(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const window = 1 << 10
+	client, server, listener := newTransportPair(t, &Config{}, &Config{
+		InitialStreamReceiveWindow: window,
+		MaxStreamReceiveWindow:     window,
+	})
+
+	accepted := make(chan *BidirectionalStream, 1)
+	server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+		accepted <- stream
+	})
+
+	stream, err := client.CreateBidirectionalStream()
+	require.NoError(t, err)
+	conn := stream.NetConn()
+
+	// The peer does not read, so the write blocks on flow control.
+	writeErr := make(chan error)
+	go func() {
+		_, wErr := conn.Write(make([]byte, 16*window))
+		writeErr <- wErr
+	}()
+	remote := <-accepted
+	select {
+	case err = <-writeErr:
+		t.Fatalf("write did not block: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Close unblocks the write and the peer sees the reset.
+	require.NoError(t, conn.Close())
+	var streamErr *StreamError
+	require.ErrorAs(t, <-writeErr, &streamErr)
+	_, err = io.ReadAll(remote)
+	require.ErrorAs(t, err, &streamErr)
+	assert.True(t, streamErr.Remote)
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
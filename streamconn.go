@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"net"
+	"time"
+)
+
+// StreamConn implements net.Conn on top of a BidirectionalStream.
+type StreamConn struct {
+	s *BidirectionalStream
+}
+
+var _ net.Conn = (*StreamConn)(nil)
+
+// Read reads data from the stream. It returns io.EOF once the peer has
+// finished the stream.
+func (c *StreamConn) Read(p []byte) (int, error) {
+	return c.s.Read(p)
+}
+
+// Write writes data to the stream.
+func (c *StreamConn) Write(p []byte) (int, error) {
+	return c.s.Writer().Write(p)
+}
+
+// Close stops receiving on the stream and finishes its sending side. If a
+// Write is blocked or data is still queued, the sending side is reset
+// instead, so that Close never waits and the pending Write returns. Close
+// fails if the sending side was already reset or stopped by the peer.
+func (c *StreamConn) Close() error {
+	if err := c.s.StopSending(0); err != nil {
+		return err
+	}
+	pending, err := c.s.wb.close()
+	if pending {
+		return c.s.Reset(0)
+	}
+
+	return err
+}
+
+// LocalAddr returns the local address of the transport.
+func (c *StreamConn) LocalAddr() net.Addr {
+	return c.s.session.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the transport.
+func (c *StreamConn) RemoteAddr() net.Addr {
+	return c.s.session.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines.
+func (c *StreamConn) SetDeadline(t time.Time) error {
+	return c.s.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future Read calls.
+func (c *StreamConn) SetReadDeadline(t time.Time) error {
+	return c.s.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future Write calls.
+func (c *StreamConn) SetWriteDeadline(t time.Time) error {
+	return c.s.SetWriteDeadline(t)
+}
+
+// Stream returns the underlying BidirectionalStream.
+func (c *StreamConn) Stream() *BidirectionalStream {
+	return c.s
+}
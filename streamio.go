@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// streamIOBufferSize is the size of the buffer ReadFrom and WriteTo copy
+// through, the same size io.Copy uses.
+const streamIOBufferSize = 32 << 10
+
+// StreamWriter adapts the sending side of a stream to io.Writer,
+// io.ReaderFrom and io.Closer. Write and ReadFrom never finish the stream,
+// Close does.
+type StreamWriter struct {
+	b *writeBuffer
+}
+
+// Write writes p to the stream.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	return w.b.write(p, false)
+}
+
+// ReadFrom writes data from r to the stream until r returns io.EOF. The
+// stream is not finished. Data is queued in the write buffer, so reading
+// from r overlaps with sending; ReadFrom returns once it was all sent.
+func (w *StreamWriter) ReadFrom(r io.Reader) (int64, error) {
+	total, err := copyChunks(r.Read, w.queue)
+	if err != nil {
+		return total, err
+	}
+
+	return total, w.b.waitBelow(context.Background(), 0, true)
+}
+
+// queue waits until p fits into the write buffer and queues it.
+func (w *StreamWriter) queue(p []byte) (int, error) {
+	threshold := w.b.max - len(p)
+	if threshold < 0 {
+		return w.b.write(p, false)
+	}
+	for {
+		if err := w.b.waitBelow(context.Background(), threshold, false); err != nil {
+			return 0, err
+		}
+		err := w.b.queue(p, false)
+		if errors.Is(err, ErrWriteBufferFull) {
+			continue // Raced with QueueWrite
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+}
+
+// Close finishes the stream, sending a FIN to the peer.
+func (w *StreamWriter) Close() error {
+	_, err := w.b.close()
+
+	return err
+}
+
+// copyChunks copies from read to write until read returns io.EOF. It backs
+// both ReadFrom and the WriteTo methods of the streams.
+func copyChunks(read, write func([]byte) (int, error)) (int64, error) {
+	buf := make([]byte, streamIOBufferSize)
+	var total int64
+	for {
+		n, err := read(buf)
+		if n > 0 {
+			written, werr := write(buf[:n])
+			total += int64(written)
+			if werr != nil {
+				return total, werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
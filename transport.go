@@ -25,10 +25,7 @@ func NewTransport(url string, config *Config) (*Transport, error) {
 		config.LoggerFactory = logging.NewDefaultLoggerFactory()
 	}
 
-	cfg := config.clone()
-	cfg.SkipVerify = true // Using self signed certificates for now
-
-	s, err := wrapper.Dial(context.Background(), url, cfg)
+	s, err := wrapper.Dial(context.Background(), url, config.clone())
 	if err != nil {
 		return nil, err
 	}
@@ -36,25 +33,17 @@ func NewTransport(url string, config *Config) (*Transport, error) {
 	t := &Transport{}
 	t.TransportBase.log = config.LoggerFactory.NewLogger("quic")
 
-	return t, t.TransportBase.startBase(s)
+	return t, t.TransportBase.startBase(s, config)
 }
 
 // single accept listen for testing.
 func newServer(url string, config *Config) (*Transport, io.Closer, error) {
-	loggerFactory := config.LoggerFactory
-	if loggerFactory == nil {
-		loggerFactory = logging.NewDefaultLoggerFactory()
-	}
-
-	cfg := config.clone()
-	cfg.SkipVerify = true // Using self signed certificates for now
-
-	list, err := wrapper.Listen(url, cfg)
+	list, err := NewListener(url, config)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	s, err := list.Accept()
+	t, err := list.Accept(context.Background())
 	if err != nil {
 		if cerr := list.Close(); cerr != nil {
 			err = fmt.Errorf("failed to close listener (%s) after accept failed: %w", cerr.Error(), err)
@@ -63,8 +52,5 @@ func newServer(url string, config *Config) (*Transport, io.Closer, error) {
 		return nil, nil, err
 	}
 
-	t := &Transport{}
-	t.TransportBase.log = loggerFactory.NewLogger("quic")
-
-	return t, list, t.TransportBase.startBase(s)
+	return t, list, nil
 }
@@ -5,9 +5,9 @@ package quic
 
 import (
 	"context"
-	"crypto"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"net"
 	"sync"
 
@@ -15,6 +15,10 @@ import (
 	"github.com/pion/quic/internal/wrapper"
 )
 
+// maxPendingStreams is the number of incoming streams per direction that are
+// buffered while no handler is set and nobody accepts them.
+const maxPendingStreams = 100
+
 // TransportBase is the base for Transport. Most of the
 // functionality of a Transport is in the base class to allow for
 // other subclasses (such as a p2p variant) to share the same interface.
@@ -22,22 +26,36 @@ type TransportBase struct {
 	lock                       sync.RWMutex
 	onBidirectionalStreamHdlr  func(*BidirectionalStream)
 	onUnidirectionalStreamHdlr func(*ReadableStream)
+	pendingBidiStreams         []*BidirectionalStream
+	pendingUniStreams          []*ReadableStream
+	bidiStreamPending          chan struct{}
+	uniStreamPending           chan struct{}
+	onStateChangeHdlr          func(TransportState)
+	onErrorHdlr                func(error)
+	onStopHdlr                 func(TransportStopInfo)
+	onRemoteAddressChangeHdlr  func(net.Addr)
+	state                      TransportState
 	session                    *wrapper.Conn
+	listener                   *wrapper.Listener
+	datagrams                  *datagramQueue
+	maxWriteBufferedAmount     int
+	streams                    streamCounters
 	log                        logging.LeveledLogger
 }
 
-// Config is used to hold the configuration of StartBase.
-type Config struct {
-	Client        bool
-	Certificate   *x509.Certificate
-	PrivateKey    crypto.PrivateKey
-	LoggerFactory logging.LoggerFactory
-}
-
 // StartBase is used to start the TransportBase. Most implementations
 // should instead use the methods on quic.Transport or
-// webrtc.QUICTransport to setup a Quic connection.
+// webrtc.QUICTransport to setup a Quic connection. conn is adapted with
+// NewPacketConn, or NewStreamPacketConn if Config.FramePackets is set, so
+// packets that are not QUIC are dropped; to share conn with other
+// protocols such as STUN, pass a MuxConn matching MatchQUIC instead.
 func (b *TransportBase) StartBase(conn net.Conn, config *Config) error {
+	if !config.Client {
+		if err := config.checkServer(); err != nil {
+			return err
+		}
+	}
+
 	lf := config.LoggerFactory
 	if lf == nil {
 		lf = logging.NewDefaultLoggerFactory()
@@ -45,43 +63,79 @@ func (b *TransportBase) StartBase(conn net.Conn, config *Config) error {
 	b.log = lf.NewLogger("quic-wrapper")
 
 	cfg := config.clone()
-	cfg.SkipVerify = true // Using self signed certificates; WebRTC will check the fingerprint
+	cfg.SkipVerify = true // Using self signed certificates; checked by Config.RemoteFingerprints
+
+	b.lock.Lock()
+	b.updateState(TransportStateConnecting)
+	b.lock.Unlock()
 
+	pconn := config.packetConn(conn)
 	var con *wrapper.Conn
 	var err error
 	if config.Client {
 		// Assumes the peer offered to be passive and we accepted.
-		con, err = wrapper.Client(context.Background(), conn, cfg)
+		con, err = wrapper.Client(context.Background(), pconn.quicConn(), pconn.RemoteAddr(), cfg)
 	} else {
 		// Assumes we offer to be passive and this is accepted.
 		var l *wrapper.Listener
-		l, err = wrapper.Server(conn, cfg)
-		if err != nil {
-			return err
+		l, err = wrapper.Server(pconn.quicConn(), cfg)
+		if err == nil {
+			b.lock.Lock()
+			b.listener = l // Closing the listener closes the session, see Stop
+			b.lock.Unlock()
+			con, err = l.Accept(context.Background())
 		}
-		con, err = l.Accept()
 	}
 
 	if err != nil {
+		b.fail(err)
+		if stopErr := b.Stop(TransportStopInfo{}); stopErr != nil {
+			b.log.Errorf("Failed to stop transport: %v", stopErr)
+		}
+
 		return err
 	}
 
-	return b.startBase(con)
+	return b.startBase(con, config)
 }
 
-func (b *TransportBase) startBase(s *wrapper.Conn) error {
+func (b *TransportBase) startBase(s *wrapper.Conn, config *Config) error {
+	b.lock.Lock()
 	b.session = s
+	b.maxWriteBufferedAmount = config.MaxWriteBufferedAmount
+	b.bidiStreamPending = make(chan struct{}, 1)
+	b.uniStreamPending = make(chan struct{}, 1)
+	select {
+	case <-s.HandshakeComplete():
+		b.updateState(TransportStateConnected)
+	default:
+		// Started early for 0-RTT; the peer is not authenticated yet.
+		b.updateState(TransportStateConnecting)
+		go b.waitHandshake(s)
+	}
+	b.lock.Unlock()
 
+	if config.EnableDatagrams {
+		b.datagrams = newDatagramQueue(config.DatagramHighWaterMark)
+		go b.datagrams.run(s.Context(), b.sendDatagram)
+	}
+
+	s.OnRemoteAddressChange(b.onRemoteAddressChange)
 	go b.acceptStreams()
 	go b.acceptUniStreams()
 
 	return nil
 }
 
-func (c *Config) clone() *wrapper.Config {
-	return &wrapper.Config{
-		Certificate: c.Certificate,
-		PrivateKey:  c.PrivateKey,
+// waitHandshake moves to the connected state once the handshake of a
+// session started before it completed.
+func (b *TransportBase) waitHandshake(s *wrapper.Conn) {
+	select {
+	case <-s.HandshakeComplete():
+		b.lock.Lock()
+		b.updateState(TransportStateConnected)
+		b.lock.Unlock()
+	case <-s.Context().Done():
 	}
 }
 
@@ -89,58 +143,338 @@ func (c *Config) clone() *wrapper.Config {
 func (b *TransportBase) CreateBidirectionalStream() (*BidirectionalStream, error) {
 	s, err := b.session.OpenStream()
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 
-	return &BidirectionalStream{
-		s: s,
-	}, nil
+	return newBidirectionalStream(s, b.session, b.maxWriteBufferedAmount, b.streams.track(false, true, true)), nil
 }
 
 // CreateUnidirectionalStream creates an QuicWritableStream object.
 func (b *TransportBase) CreateUnidirectionalStream() (*WritableStream, error) {
 	s, err := b.session.OpenUniStream()
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
+	}
+
+	return newWritableStream(s, b.maxWriteBufferedAmount, b.streams.track(false, false, true)), nil
+}
+
+// CreateBidirectionalStreamContext creates an QuicBidirectionalStream
+// object. Unlike CreateBidirectionalStream it waits until the peer allows
+// another stream to be opened, or until ctx is done.
+func (b *TransportBase) CreateBidirectionalStreamContext(ctx context.Context) (*BidirectionalStream, error) {
+	s, err := b.session.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	return newBidirectionalStream(s, b.session, b.maxWriteBufferedAmount, b.streams.track(false, true, true)), nil
+}
+
+// CreateUnidirectionalStreamContext creates an QuicWritableStream object.
+// Unlike CreateUnidirectionalStream it waits until the peer allows another
+// stream to be opened, or until ctx is done.
+func (b *TransportBase) CreateUnidirectionalStreamContext(ctx context.Context) (*WritableStream, error) {
+	s, err := b.session.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, wrapError(err)
 	}
 
-	return &WritableStream{
-		s: s,
-	}, nil
+	return newWritableStream(s, b.maxWriteBufferedAmount, b.streams.track(false, false, true)), nil
 }
 
 // OnBidirectionalStream allows setting an event handler for that is fired
 // when data is received from a BidirectionalStream for the first time.
+// Streams that arrived before the handler was set are passed to it as well.
 func (b *TransportBase) OnBidirectionalStream(f func(*BidirectionalStream)) {
 	b.lock.Lock()
-	defer b.lock.Unlock()
 	b.onBidirectionalStreamHdlr = f
+	var pending []*BidirectionalStream
+	if f != nil {
+		pending, b.pendingBidiStreams = b.pendingBidiStreams, nil
+	}
+	b.lock.Unlock()
+
+	for _, s := range pending {
+		go f(s)
+	}
 }
 
 // OnUnidirectionalStream allows setting an event handler for that is fired
 // when data is received from a UnidirectionalStream for the first time.
+// Streams that arrived before the handler was set are passed to it as well.
 func (b *TransportBase) OnUnidirectionalStream(f func(*ReadableStream)) {
 	b.lock.Lock()
-	defer b.lock.Unlock()
 	b.onUnidirectionalStreamHdlr = f
+	var pending []*ReadableStream
+	if f != nil {
+		pending, b.pendingUniStreams = b.pendingUniStreams, nil
+	}
+	b.lock.Unlock()
+
+	for _, s := range pending {
+		go f(s)
+	}
+}
+
+// AcceptBidirectionalStream waits for the next incoming BidirectionalStream.
+// It is an alternative to OnBidirectionalStream and only returns streams
+// while no handler is set.
+func (b *TransportBase) AcceptBidirectionalStream(ctx context.Context) (*BidirectionalStream, error) {
+	for {
+		b.lock.Lock()
+		if len(b.pendingBidiStreams) > 0 {
+			s := b.pendingBidiStreams[0]
+			b.pendingBidiStreams = b.pendingBidiStreams[1:]
+			if len(b.pendingBidiStreams) > 0 {
+				notifyPending(b.bidiStreamPending) // Wake up the next waiter
+			}
+			b.lock.Unlock()
+
+			return s, nil
+		}
+		pending := b.bidiStreamPending
+		b.lock.Unlock()
+
+		if err := b.waitPending(ctx, pending); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// AcceptUnidirectionalStream waits for the next incoming ReadableStream. It
+// is an alternative to OnUnidirectionalStream and only returns streams
+// while no handler is set.
+func (b *TransportBase) AcceptUnidirectionalStream(ctx context.Context) (*ReadableStream, error) {
+	for {
+		b.lock.Lock()
+		if len(b.pendingUniStreams) > 0 {
+			s := b.pendingUniStreams[0]
+			b.pendingUniStreams = b.pendingUniStreams[1:]
+			if len(b.pendingUniStreams) > 0 {
+				notifyPending(b.uniStreamPending) // Wake up the next waiter
+			}
+			b.lock.Unlock()
+
+			return s, nil
+		}
+		pending := b.uniStreamPending
+		b.lock.Unlock()
+
+		if err := b.waitPending(ctx, pending); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (b *TransportBase) waitPending(ctx context.Context, pending <-chan struct{}) error {
+	session := b.session.Context()
+	select {
+	case <-pending:
+		return nil
+	case <-session.Done():
+		return wrapError(context.Cause(session))
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnStateChange sets an event handler which is fired when the state of the
+// TransportBase changes.
+func (b *TransportBase) OnStateChange(f func(TransportState)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.onStateChangeHdlr = f
+}
+
+// OnError sets an event handler which is fired when the TransportBase fails
+// because of an error.
+func (b *TransportBase) OnError(f func(error)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.onErrorHdlr = f
+}
+
+// OnStop sets an event handler which is fired when the peer stops the
+// TransportBase. It receives the TransportStopInfo the peer passed to Stop.
+func (b *TransportBase) OnStop(f func(TransportStopInfo)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.onStopHdlr = f
+}
+
+// State returns the current state of the TransportBase.
+func (b *TransportBase) State() TransportState {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.state
+}
+
+// updateState moves to the given state and fires the state change handler.
+// Closed and failed are final states. b.lock must be held.
+func (b *TransportBase) updateState(state TransportState) bool {
+	if b.state == state || b.state == TransportStateClosed || b.state == TransportStateFailed {
+		return false
+	}
+	b.state = state
+
+	if f := b.onStateChangeHdlr; f != nil {
+		go f(state)
+	}
+
+	return true
+}
+
+// fail moves to the failed state and fires the error handler, unless the
+// TransportBase has already been closed.
+func (b *TransportBase) fail(err error) bool {
+	b.lock.Lock()
+	failed := b.updateState(TransportStateFailed)
+	f := b.onErrorHdlr
+	b.lock.Unlock()
+
+	if failed && f != nil {
+		go f(err)
+	}
+
+	return failed
 }
 
 func (b *TransportBase) onBidirectionalStream(s *BidirectionalStream) {
 	b.lock.Lock()
 	f := b.onBidirectionalStreamHdlr
+	queued := false
+	if f == nil && len(b.pendingBidiStreams) < maxPendingStreams {
+		b.pendingBidiStreams = append(b.pendingBidiStreams, s)
+		queued = true
+	}
 	b.lock.Unlock()
-	if f != nil {
+
+	switch {
+	case f != nil:
 		go f(s)
+	case queued:
+		notifyPending(b.bidiStreamPending)
+	default:
+		b.log.Warnf("Rejecting stream %d: too many pending streams", s.StreamID())
+		_ = s.StopSending(0)
+		_ = s.Reset(0)
 	}
 }
 
 func (b *TransportBase) onUnidirectionalStream(s *ReadableStream) {
 	b.lock.Lock()
 	f := b.onUnidirectionalStreamHdlr
+	queued := false
+	if f == nil && len(b.pendingUniStreams) < maxPendingStreams {
+		b.pendingUniStreams = append(b.pendingUniStreams, s)
+		queued = true
+	}
 	b.lock.Unlock()
-	if f != nil {
+
+	switch {
+	case f != nil:
 		go f(s)
+	case queued:
+		notifyPending(b.uniStreamPending)
+	default:
+		b.log.Warnf("Rejecting stream %d: too many pending streams", s.StreamID())
+		_ = s.StopSending(0)
+	}
+}
+
+func notifyPending(pending chan<- struct{}) {
+	select {
+	case pending <- struct{}{}:
+	default:
+	}
+}
+
+// SendDatagram queues data to be sent in an unreliable datagram. If the
+// queue has reached its high-water mark the oldest queued datagram is
+// dropped. Datagrams are handed to the QUIC stack one at a time, as packets
+// are sent, so a stale datagram is dropped here rather than sent late.
+// Data larger than MaxDatagramSize is rejected with ErrDatagramTooLarge.
+func (b *TransportBase) SendDatagram(data []byte) error {
+	if b.datagrams == nil || !b.session.SupportsDatagrams() {
+		return ErrDatagramsDisabled
+	}
+	if maxSize := b.session.MaxDatagramSize(); len(data) > maxSize {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrDatagramTooLarge, len(data), maxSize)
+	}
+
+	b.datagrams.push(append([]byte(nil), data...))
+
+	return nil
+}
+
+// ReadyToSendDatagram blocks until the datagram queue is below its
+// high-water mark, so the next SendDatagram will not drop a datagram.
+func (b *TransportBase) ReadyToSendDatagram(ctx context.Context) error {
+	if b.datagrams == nil {
+		return ErrDatagramsDisabled
+	}
+
+	return b.datagrams.wait(ctx, b.session.Context())
+}
+
+// ReceiveDatagram blocks until a datagram is received or ctx is done.
+func (b *TransportBase) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	if b.datagrams == nil {
+		return nil, ErrDatagramsDisabled
 	}
+
+	data, err := b.session.ReceiveDatagram(ctx)
+
+	return data, wrapError(err)
+}
+
+// MaxDatagramSize returns the largest datagram payload that can currently
+// be sent, or 0 if datagrams are not supported.
+func (b *TransportBase) MaxDatagramSize() int {
+	if b.datagrams == nil {
+		return 0
+	}
+
+	return b.session.MaxDatagramSize()
+}
+
+// sendDatagram hands data to the session and waits until it was sent, see
+// datagramQueue.run. Failed datagrams are only counted as dropped, since
+// the MTU can shrink after SendDatagram checked the size.
+func (b *TransportBase) sendDatagram(data []byte) error {
+	return b.session.SendDatagramPaced(b.session.Context(), data)
+}
+
+// HandshakeComplete returns a channel that is closed once the handshake
+// completed. With Config.Enable0RTT a Transport is started before, and
+// the peer certificate is only verified once it is closed. The Transport
+// stays in TransportStateConnecting until then.
+func (b *TransportBase) HandshakeComplete() <-chan struct{} {
+	return b.session.HandshakeComplete()
+}
+
+// DidResume reports whether the connection resumed a TLS session, see
+// Config.ClientSessionCache. It is only final once the handshake completed.
+func (b *TransportBase) DidResume() bool {
+	return b.session.DidResume()
+}
+
+// Used0RTT reports whether the server accepted 0-RTT data. It is only
+// final once the handshake completed.
+func (b *TransportBase) Used0RTT() bool {
+	return b.session.Used0RTT()
+}
+
+// NegotiatedProtocol returns the application protocol selected by ALPN
+// during the handshake, see Config.NextProtos.
+func (b *TransportBase) NegotiatedProtocol() string {
+	if b.session == nil {
+		return ""
+	}
+
+	return b.session.NegotiatedProtocol()
 }
 
 // GetRemoteCertificates returns the certificate chain in use by the remote side.
@@ -152,22 +486,13 @@ func (b *TransportBase) acceptStreams() {
 	for {
 		stream, err := b.session.AcceptStream()
 		if err != nil {
-			b.log.Errorf("Failed to accept stream: %v", err)
-			stopErr := b.Stop(TransportStopInfo{
-				Reason: err.Error(),
-			})
-			if stopErr != nil {
-				b.log.Errorf("Failed to stop transport: %v", stopErr)
-			}
+			b.handleAcceptError(wrapError(err))
 
 			return
 		}
-		if stream != nil {
-			stream := &BidirectionalStream{s: stream}
-			b.onBidirectionalStream(stream)
-		} else {
-			return
-		}
+		b.onBidirectionalStream(newBidirectionalStream(
+			stream, b.session, b.maxWriteBufferedAmount, b.streams.track(true, true, true),
+		))
 	}
 }
 
@@ -175,36 +500,72 @@ func (b *TransportBase) acceptUniStreams() {
 	for {
 		stream, err := b.session.AcceptUniStream()
 		if err != nil {
-			b.log.Errorf("Failed to accept stream: %v", err)
-			stopErr := b.Stop(TransportStopInfo{
-				Reason: err.Error(),
-			})
-			if stopErr != nil {
-				b.log.Errorf("Failed to stop transport: %v", stopErr)
-			}
+			b.handleAcceptError(wrapError(err))
 
 			return
 		}
-		if stream != nil {
-			stream := &ReadableStream{s: stream}
-			b.onUnidirectionalStream(stream)
-		} else {
-			return
+		b.onUnidirectionalStream(newReadableStream(stream, b.streams.track(true, true, false)))
+	}
+}
+
+// handleAcceptError closes the TransportBase after an accept loop ended.
+// A TransportError means the session was stopped by either side.
+func (b *TransportBase) handleAcceptError(err error) {
+	var transportErr *TransportError
+	if errors.As(err, &transportErr) {
+		b.lock.Lock()
+		closed := b.updateState(TransportStateClosed)
+		f := b.onStopHdlr
+		b.lock.Unlock()
+
+		if closed && transportErr.Remote && f != nil {
+			go f(TransportStopInfo{
+				ErrorCode: transportErr.ErrorCode,
+				Reason:    transportErr.Reason,
+			})
 		}
+
+		return
+	}
+
+	if !b.fail(err) {
+		return // Already closed or failed
+	}
+
+	b.log.Errorf("Failed to accept stream: %v", err)
+	stopErr := b.Stop(TransportStopInfo{
+		Reason: err.Error(),
+	})
+	if stopErr != nil {
+		b.log.Errorf("Failed to stop transport: %v", stopErr)
 	}
 }
 
 // Stop stops and closes the TransportBase.
 func (b *TransportBase) Stop(stopInfo TransportStopInfo) error {
+	if err := stopInfo.ErrorCode.validate(); err != nil {
+		return err
+	}
+
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
+	b.updateState(TransportStateClosed)
+
+	if b.listener != nil {
+		defer func() {
+			if err := b.listener.Close(); err != nil {
+				b.log.Warnf("Failed to close listener: %v", err)
+			}
+		}()
+	}
+
 	if b.session == nil {
 		return nil
 	}
 
 	if stopInfo.ErrorCode > 0 || len(stopInfo.Reason) > 0 {
-		return b.session.CloseWithError(stopInfo.ErrorCode, errors.New(stopInfo.Reason)) //nolint:err113
+		return b.session.CloseWithError(uint64(stopInfo.ErrorCode), errors.New(stopInfo.Reason)) //nolint:err113
 	}
 
 	return b.session.Close()
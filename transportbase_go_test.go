@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTransportPair connects a client Transport to a Transport accepted by a
+// new Listener. Certificates are generated if the configs do not have one.
+func newTransportPair(t *testing.T, clientCfg, serverCfg *Config) (*Transport, *Transport, *Listener) {
+	t.Helper()
+
+	for _, cfg := range []*Config{clientCfg, serverCfg} {
+		if cfg.Certificate == nil {
+			cert, key, err := GenerateSelfSigned()
+			require.NoError(t, err)
+			cfg.Certificate, cfg.PrivateKey = cert, key
+		}
+	}
+
+	listener, err := NewListener("localhost:0", serverCfg)
+	require.NoError(t, err)
+
+	accepted := make(chan *Transport)
+	go func() {
+		defer close(accepted)
+		server, aErr := listener.Accept(context.Background())
+		assert.NoError(t, aErr)
+		accepted <- server
+	}()
+
+	client, err := NewTransport(listener.Addr().String(), clientCfg)
+	require.NoError(t, err)
+
+	server := <-accepted
+	require.NotNil(t, server)
+
+	return client, server, listener
+}
+
+func TestTransportBase_StateChange(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+	assert.Equal(t, TransportStateConnected, client.State())
+	assert.Equal(t, TransportStateConnected, server.State())
+
+	serverStates := make(chan TransportState, 1)
+	server.OnStateChange(func(state TransportState) {
+		serverStates <- state
+	})
+	server.OnError(func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+
+	assert.NoError(t, client.Stop(TransportStopInfo{}))
+	assert.Equal(t, TransportStateClosed, client.State())
+	assert.Equal(t, TransportStateClosed, <-serverStates)
+	assert.Equal(t, TransportStateClosed, server.State())
+
+	// Closed is a final state.
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.Equal(t, TransportStateClosed, server.State())
+
+	assert.NoError(t, listener.Close())
+}
+
+func TestTransportBase_OnStop(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	client, server, listener := newTransportPair(t, &Config{}, &Config{})
+
+	stopInfos := make(chan TransportStopInfo, 1)
+	server.OnStop(func(info TransportStopInfo) {
+		stopInfos <- info
+	})
+	client.OnStop(func(TransportStopInfo) {
+		t.Error("OnStop fired for a local Stop")
+	})
+
+	assert.ErrorIs(t, client.Stop(TransportStopInfo{ErrorCode: MaxErrorCode + 1}), ErrInvalidErrorCode)
+	assert.Equal(t, TransportStateConnected, client.State())
+
+	// The largest varint has to survive the round trip.
+	stopInfo := TransportStopInfo{ErrorCode: MaxErrorCode, Reason: "server shutdown"}
+	assert.NoError(t, client.Stop(stopInfo))
+	assert.Equal(t, stopInfo, <-stopInfos)
+	assert.Equal(t, TransportStateClosed, server.State())
+
+	assert.NoError(t, server.Stop(TransportStopInfo{}))
+	assert.NoError(t, listener.Close())
+}
+
+func TestTransportState_String(t *testing.T) {
+	for state, want := range map[TransportState]string{
+		TransportStateNew:        "new",
+		TransportStateConnecting: "connecting",
+		TransportStateConnected:  "connected",
+		TransportStateClosed:     "closed",
+		TransportStateFailed:     "failed",
+		TransportState(42):       "unknown",
+	} {
+		assert.Equal(t, want, state.String())
+	}
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+// TransportState indicates the state of a Transport, following
+// RTCQuicTransportState.
+type TransportState int
+
+const (
+	// TransportStateNew indicates the Transport has not been started yet.
+	TransportStateNew TransportState = iota
+
+	// TransportStateConnecting indicates the QUIC handshake is in progress.
+	TransportStateConnecting
+
+	// TransportStateConnected indicates the QUIC handshake has completed.
+	TransportStateConnected
+
+	// TransportStateClosed indicates the Transport has been stopped by
+	// either side.
+	TransportStateClosed
+
+	// TransportStateFailed indicates the Transport has been closed because
+	// of an error.
+	TransportStateFailed
+)
+
+func (s TransportState) String() string {
+	switch s {
+	case TransportStateNew:
+		return "new"
+	case TransportStateConnecting:
+		return "connecting"
+	case TransportStateConnected:
+		return "connected"
+	case TransportStateClosed:
+		return "closed"
+	case TransportStateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
@@ -6,6 +6,6 @@ package quic
 // TransportStopInfo holds information relating to the error code for
 // stopping a TransportBase.
 type TransportStopInfo struct {
-	ErrorCode uint16
+	ErrorCode ErrorCode
 	Reason    string
 }
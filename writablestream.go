@@ -4,6 +4,7 @@
 package quic
 
 import (
+	"context"
 	"time"
 
 	"github.com/pion/quic/internal/wrapper"
@@ -12,12 +13,60 @@ import (
 
 // WritableStream represents a quic SendStream.
 type WritableStream struct {
-	s *wrapper.WritableStream
+	s  *wrapper.WritableStream
+	wb *writeBuffer
 }
 
-// Write writes data to the stream.
+func newWritableStream(
+	s *wrapper.WritableStream, maxWriteBufferedAmount int, lifetime *streamLifetime,
+) *WritableStream {
+	return &WritableStream{
+		s:  s,
+		wb: newWriteBuffer(s, maxWriteBufferedAmount, lifetime),
+	}
+}
+
+// Write writes data to the stream. Data queued with QueueWrite is sent
+// first.
 func (s *WritableStream) Write(data StreamWriteParameters) error {
-	_, err := s.s.WriteQuic(data.Data, data.Finished)
+	_, err := s.wb.write(data.Data, data.Finished)
+
+	return err
+}
+
+// QueueWrite copies data into the stream's write buffer and returns without
+// waiting for it to be sent. It fails with ErrWriteBufferFull if the data
+// does not fit, see WaitForWriteBufferedAmountBelow.
+func (s *WritableStream) QueueWrite(data StreamWriteParameters) error {
+	return s.wb.queue(data.Data, data.Finished)
+}
+
+// WriteBufferedAmount returns the number of bytes queued but not yet
+// handed to the QUIC stack.
+func (s *WritableStream) WriteBufferedAmount() int {
+	return s.wb.bufferedAmount()
+}
+
+// MaxWriteBufferedAmount returns the size of the write buffer.
+func (s *WritableStream) MaxWriteBufferedAmount() int {
+	return s.wb.max
+}
+
+// WaitForWriteBufferedAmountBelow blocks until WriteBufferedAmount is at or
+// below threshold, or ctx is done. It fails if sending queued data failed.
+func (s *WritableStream) WaitForWriteBufferedAmountBelow(ctx context.Context, threshold int) error {
+	return s.wb.waitBelow(ctx, threshold, false)
+}
+
+// Writer returns an io.WriteCloser for the stream.
+func (s *WritableStream) Writer() *StreamWriter {
+	return &StreamWriter{b: s.wb}
+}
+
+// Close implements io.Closer, finishing the stream without waiting. The FIN
+// is sent to the peer after any queued or pending data.
+func (s *WritableStream) Close() error {
+	_, err := s.wb.close()
 
 	return err
 }
@@ -27,9 +76,27 @@ func (s *WritableStream) StreamID() StreamID {
 	return StreamID(s.s.StreamID())
 }
 
+// Reset aborts writing to the stream, following abortWriting. Data that
+// has not been delivered yet is discarded and the peer's reads fail with a
+// StreamError carrying code.
+func (s *WritableStream) Reset(code ErrorCode) error {
+	if err := code.validate(); err != nil {
+		return err
+	}
+	s.s.CancelWrite(uint64(code))
+	s.wb.lifetime.closeWrite()
+
+	return nil
+}
+
 // SetWriteDeadline sets the deadline for future Write calls. A zero value for t means Write will not time out.
 func (s *WritableStream) SetWriteDeadline(t time.Time) error {
-	return s.s.SetWriteDeadline(t)
+	// The flusher retries once it sees the new deadline, so the stream's
+	// deadline has to be moved first.
+	err := s.s.SetWriteDeadline(t)
+	s.wb.setWriteDeadline(t)
+
+	return err
 }
 
 // Detach detaches the underlying quic-go SendStream.
@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package quic
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultMaxWriteBufferedAmount is the size of a stream's write buffer when
+// Config.MaxWriteBufferedAmount is not set.
+const defaultMaxWriteBufferedAmount = 1 << 20
+
+type quicWriter interface {
+	WriteQuic(p []byte, fin bool) (int, error)
+	Close() error
+	CancelWrite(code uint64)
+	Context() context.Context
+}
+
+type writeChunk struct {
+	data []byte
+	fin  bool
+}
+
+// writeBuffer queues data written with QueueWrite and hands it to the stream
+// from a flusher goroutine that exists only while the buffer is not empty.
+// Synchronous writes wait for the buffer to drain so data stays in order.
+// Finishing the stream never waits: the FIN is sent right away if nothing
+// is pending, and queued behind the pending data otherwise.
+type writeBuffer struct {
+	w        quicWriter
+	max      int
+	lifetime *streamLifetime
+
+	// writeLock serializes writes to w.
+	writeLock sync.Mutex
+
+	lock     sync.Mutex
+	chunks   []writeChunk
+	amount   int
+	finished bool
+	flushing bool
+	err      error
+	deadline time.Time
+	changed  chan struct{}
+}
+
+func newWriteBuffer(w quicWriter, maxAmount int, lifetime *streamLifetime) *writeBuffer {
+	if maxAmount <= 0 {
+		maxAmount = defaultMaxWriteBufferedAmount
+	}
+
+	return &writeBuffer{
+		w:        w,
+		max:      maxAmount,
+		lifetime: lifetime,
+		changed:  make(chan struct{}),
+	}
+}
+
+// queue copies data into the buffer and starts the flusher if needed.
+func (b *writeBuffer) queue(data []byte, fin bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch {
+	case b.err != nil:
+		return b.err
+	case b.finished:
+		return ErrStreamFinished
+	case len(data) > b.max-b.amount:
+		return ErrWriteBufferFull
+	}
+
+	b.chunks = append(b.chunks, writeChunk{data: append([]byte(nil), data...), fin: fin})
+	b.amount += len(data)
+	b.finished = fin
+	if !b.flushing {
+		b.flushing = true
+		go b.flush()
+	}
+
+	return nil
+}
+
+func (b *writeBuffer) flush() {
+	for {
+		b.lock.Lock()
+		if len(b.chunks) == 0 {
+			b.flushing = false
+			b.notify()
+			b.lock.Unlock()
+
+			return
+		}
+		chunk := b.chunks[0]
+		deadline := b.deadline
+		b.lock.Unlock()
+
+		b.writeLock.Lock()
+		var n int
+		var err error
+		if len(chunk.data) == 0 && chunk.fin {
+			// The FIN queued by close must not fail on a write deadline.
+			err = b.w.Close()
+		} else {
+			n, err = b.w.WriteQuic(chunk.data, chunk.fin)
+		}
+		b.writeLock.Unlock()
+
+		if isTimeout(err) {
+			if err = b.resumeAfterTimeout(n, deadline); err == nil {
+				continue
+			}
+			n = 0
+		}
+		if chunk.fin || err != nil {
+			b.lifetime.closeWrite()
+		}
+
+		b.lock.Lock()
+		b.chunks[0] = writeChunk{}
+		b.chunks = b.chunks[1:]
+		b.amount -= len(chunk.data)
+		if err != nil {
+			if n < len(chunk.data) || len(b.chunks) > 0 {
+				// Tell the peer that the stream is incomplete.
+				b.w.CancelWrite(0)
+			}
+			b.err = wrapStreamError(err, true)
+			b.chunks = nil
+			b.amount = 0
+		}
+		b.notify()
+		b.lock.Unlock()
+	}
+}
+
+// resumeAfterTimeout keeps the unsent part of the first chunk after the
+// write deadline interrupted it, and waits until the deadline is moved. The
+// deadline is shared with synchronous writes, so timing out does not fail
+// the queued data. It returns why the stream is done if that happens first.
+func (b *writeBuffer) resumeAfterTimeout(sent int, deadline time.Time) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.chunks[0].data = b.chunks[0].data[sent:]
+	b.amount -= sent
+	b.notify()
+
+	ctx := b.w.Context()
+	for b.deadline.Equal(deadline) {
+		changed := b.changed
+		b.lock.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			b.lock.Lock()
+
+			return context.Cause(ctx)
+		}
+		b.lock.Lock()
+	}
+
+	return nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// notify wakes up everyone waiting on the buffer. It requires the lock.
+func (b *writeBuffer) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// waitBelow blocks until at most threshold bytes are buffered, and until
+// the buffer is idle if idle is set. Waiting for an idle buffer is part of
+// a synchronous write, so it also ends at the write deadline.
+func (b *writeBuffer) waitBelow(ctx context.Context, threshold int, idle bool) error {
+	for {
+		b.lock.Lock()
+		if b.err != nil {
+			err := b.err
+			b.lock.Unlock()
+
+			return err
+		}
+		if b.amount <= threshold && (!idle || !b.flushing) {
+			b.lock.Unlock()
+
+			return nil
+		}
+		changed := b.changed
+		deadline := b.deadline
+		b.lock.Unlock()
+
+		if !idle {
+			deadline = time.Time{}
+		}
+		if err := waitChanged(ctx, changed, deadline); err != nil {
+			return err
+		}
+	}
+}
+
+// waitChanged waits for changed to be closed, ctx to be done or the
+// deadline, unless it is zero, to pass.
+func waitChanged(ctx context.Context, changed <-chan struct{}, deadline time.Time) error {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-changed:
+		return nil
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// setWriteDeadline records the deadline for synchronous writes that wait
+// for queued data.
+func (b *writeBuffer) setWriteDeadline(t time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.deadline = t
+	b.notify()
+}
+
+// write writes data directly once previously queued data has been sent.
+func (b *writeBuffer) write(data []byte, fin bool) (int, error) {
+	b.lock.Lock()
+	finished := b.finished
+	b.lock.Unlock()
+	if finished {
+		return 0, ErrStreamFinished
+	}
+	if err := b.waitBelow(context.Background(), 0, true); err != nil {
+		return 0, err
+	}
+
+	b.writeLock.Lock()
+	defer b.writeLock.Unlock()
+
+	b.lock.Lock()
+	finished = b.finished
+	b.finished = b.finished || fin
+	b.lock.Unlock()
+	if finished {
+		return 0, ErrStreamFinished
+	}
+
+	n, err := b.w.WriteQuic(data, fin)
+	switch {
+	case isTimeout(err):
+		if fin {
+			// The FIN was not sent, so the stream can still be finished.
+			b.lock.Lock()
+			b.finished = false
+			b.lock.Unlock()
+		}
+	case fin || err != nil:
+		b.lifetime.closeWrite()
+	}
+
+	return n, wrapStreamError(err, true)
+}
+
+// close finishes the stream without waiting. If data is still pending, the
+// FIN is queued behind it and close reports true.
+func (b *writeBuffer) close() (bool, error) {
+	b.lock.Lock()
+	switch {
+	case b.err != nil:
+		b.lock.Unlock()
+
+		return false, b.err
+	case b.finished:
+		b.lock.Unlock()
+
+		return false, nil
+	}
+	b.finished = true
+
+	// A synchronous write holds writeLock while it is blocked on flow
+	// control; the flusher sends the FIN once it is done.
+	if b.flushing || !b.writeLock.TryLock() {
+		b.chunks = append(b.chunks, writeChunk{fin: true})
+		if !b.flushing {
+			b.flushing = true
+			go b.flush()
+		}
+		b.lock.Unlock()
+
+		return true, nil
+	}
+	b.lock.Unlock()
+
+	defer b.writeLock.Unlock()
+	b.lifetime.closeWrite()
+
+	return false, wrapStreamError(b.w.Close(), true)
+}
+
+func (b *writeBuffer) bufferedAmount() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.amount
+}
@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2023 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+// +build !js
+
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v3/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport_ZeroRTT(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cert, key, err := GenerateSelfSigned()
+	require.NoError(t, err)
+
+	accept0RTT := make(chan bool, 1)
+	listener, err := NewListener("localhost:0", &Config{
+		Certificate: cert,
+		PrivateKey:  key,
+		Enable0RTT:  true,
+		Accept0RTT: func(remoteAddr net.Addr, _ bool) bool {
+			assert.NotNil(t, remoteAddr)
+
+			return <-accept0RTT
+		},
+	})
+	require.NoError(t, err)
+
+	clientCfg := &Config{
+		Certificate:        cert,
+		PrivateKey:         key,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+		Enable0RTT:         true,
+	}
+
+	// connect sends ping on a new stream and waits for the echo.
+	connect := func(t *testing.T, accept bool) *Transport {
+		t.Helper()
+
+		accept0RTT <- accept
+		accepted := make(chan *Transport, 1)
+		go func() {
+			server, aErr := listener.Accept(context.Background())
+			assert.NoError(t, aErr)
+			server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+				_, cErr := io.Copy(stream.Writer(), stream)
+				assert.NoError(t, cErr)
+				assert.NoError(t, stream.CloseWrite())
+			})
+			accepted <- server
+		}()
+
+		client, err := NewTransport(listener.Addr().String(), clientCfg)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := client.CreateBidirectionalStreamContext(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("ping"), Finished: true}))
+		data, err := io.ReadAll(stream)
+		if accept {
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(data))
+		} else {
+			// The stream was sent as 0-RTT data and is lost.
+			assert.ErrorIs(t, err, Err0RTTRejected)
+		}
+		<-client.HandshakeComplete()
+
+		server := <-accepted
+		assert.NoError(t, client.Stop(TransportStopInfo{}))
+		assert.NoError(t, server.Stop(TransportStopInfo{}))
+
+		return client
+	}
+
+	// The first connection does a full handshake and receives a ticket.
+	client := connect(t, true)
+	assert.False(t, client.DidResume())
+	assert.False(t, client.Used0RTT())
+
+	client = connect(t, true)
+	assert.True(t, client.DidResume())
+	assert.True(t, client.Used0RTT())
+
+	client = connect(t, false)
+	assert.True(t, client.DidResume())
+	assert.False(t, client.Used0RTT())
+
+	assert.NoError(t, listener.Close())
+}
+
+// delayProxy forwards UDP packets between a client and server, delaying the
+// packets from the server. With a negative delay they are dropped.
+func delayProxy(t *testing.T, server net.Addr, delay time.Duration) (*net.UDPConn, *net.UDPConn) {
+	t.Helper()
+
+	serverAddr, ok := server.(*net.UDPAddr)
+	require.True(t, ok)
+	proxy, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	upstream, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: serverAddr.Port})
+	require.NoError(t, err)
+
+	var client atomic.Pointer[net.UDPAddr]
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, rErr := proxy.ReadFromUDP(buf)
+			if rErr != nil {
+				return
+			}
+			client.Store(addr)
+			_, _ = upstream.Write(buf[:n])
+		}
+	}()
+	go func() {
+		for {
+			buf := make([]byte, 1500)
+			n, rErr := upstream.Read(buf)
+			if rErr != nil {
+				return
+			}
+			if delay < 0 {
+				continue
+			}
+			time.AfterFunc(delay, func() {
+				_, _ = proxy.WriteToUDP(buf[:n], client.Load())
+			})
+		}
+	}()
+
+	return proxy, upstream
+}
+
+func TestTransport_ZeroRTTBeforeHandshake(t *testing.T) {
+	// Limit runtime in case of deadlocks
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	cert, key, err := GenerateSelfSigned()
+	require.NoError(t, err)
+
+	listener, err := NewListener("localhost:0", &Config{
+		Certificate: cert,
+		PrivateKey:  key,
+		Enable0RTT:  true,
+	})
+	require.NoError(t, err)
+
+	type request struct {
+		data              string
+		handshakeComplete bool
+		state             TransportState
+	}
+	requests := make(chan request, 1)
+	servers := make(chan *Transport, 2)
+	go func() {
+		for {
+			server, aErr := listener.Accept(context.Background())
+			if aErr != nil {
+				return
+			}
+			server.OnBidirectionalStream(func(stream *BidirectionalStream) {
+				data, rErr := io.ReadAll(stream)
+				assert.NoError(t, rErr)
+				state := server.State()
+				select {
+				case <-server.HandshakeComplete():
+					requests <- request{string(data), true, state}
+				default:
+					requests <- request{string(data), false, state}
+				}
+				assert.NoError(t, stream.Write(StreamWriteParameters{Data: data, Finished: true}))
+			})
+			servers <- server
+		}
+	}()
+
+	clientCfg := &Config{
+		Certificate:        cert,
+		PrivateKey:         key,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+		Enable0RTT:         true,
+	}
+	connect := func(addr string) *Transport {
+		client, cErr := NewTransport(addr, clientCfg)
+		require.NoError(t, cErr)
+		stream, cErr := client.CreateBidirectionalStream()
+		require.NoError(t, cErr)
+		require.NoError(t, stream.Write(StreamWriteParameters{Data: []byte("ping"), Finished: true}))
+		data, cErr := io.ReadAll(stream)
+		require.NoError(t, cErr)
+		assert.Equal(t, "ping", string(data))
+		<-client.HandshakeComplete()
+		assert.Eventually(t, func() bool {
+			return client.State() == TransportStateConnected
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, client.Stop(TransportStopInfo{}))
+		assert.NoError(t, (<-servers).Stop(TransportStopInfo{}))
+
+		return client
+	}
+
+	// The first connection receives a session ticket.
+	assert.False(t, connect(listener.Addr().String()).Used0RTT())
+	<-requests
+
+	// The server's flight is delayed, so the handshake cannot complete
+	// before the server reads the 0-RTT request.
+	proxy, upstream := delayProxy(t, listener.Addr(), 300*time.Millisecond)
+	assert.True(t, connect(proxy.LocalAddr().String()).Used0RTT())
+	req := <-requests
+	assert.Equal(t, "ping", req.data)
+	assert.False(t, req.handshakeComplete)
+	// The peer is not authenticated before the handshake completed.
+	assert.Equal(t, TransportStateConnecting, req.state)
+
+	assert.NoError(t, proxy.Close())
+	assert.NoError(t, upstream.Close())
+	assert.NoError(t, listener.Close())
+}